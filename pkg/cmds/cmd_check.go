@@ -0,0 +1,47 @@
+package cmds
+
+import (
+	"fmt"
+
+	"github.com/mdevilliers/depender/pkg/dependabot"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func checkCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "check",
+		Usage: "fail if the dependabot.yml file is missing any detected updates",
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:  "tracked-only",
+				Usage: "only scan files tracked by git",
+			},
+		}, repoFlags()...),
+		Action: func(c *cli.Context) error {
+			path := c.Args().First()
+
+			s, err := dependabot.LoadOrCreate(path, loadOptions(c)...)
+			if err != nil {
+				return errors.Wrap(err, "error loading configuration")
+			}
+
+			plan, err := s.Plan(dependabot.ScanOptions{
+				TrackedOnly: c.Value("tracked-only").(bool),
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(plan.Added) == 0 {
+				return nil
+			}
+
+			for _, u := range plan.Added {
+				fmt.Fprintf(c.App.Writer, "missing: %s %s\n", u.PackageEcoSystem, directories(u))
+			}
+
+			return errors.Errorf("%s is missing %d update(s)", plan.Path, len(plan.Added))
+		},
+	}
+}
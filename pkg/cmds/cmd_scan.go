@@ -1,6 +1,9 @@
 package cmds
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/mdevilliers/depender/pkg/dependabot"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
@@ -9,33 +12,165 @@ import (
 func scanCmd() *cli.Command {
 	return &cli.Command{
 		Name: "scan",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:    "create-if-missing",
 				Aliases: []string{"c"},
 				Usage:   "create dependabot.yml file if missing. Defaults to .github/dependabot.yml path",
 			},
-		},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "print a unified diff of the changes instead of writing them",
+			},
+			&cli.BoolFlag{
+				Name:  "sort",
+				Usage: "sort all updates, including existing ones, by ecosystem then directory",
+			},
+			&cli.BoolFlag{
+				Name:  "prune",
+				Usage: "remove existing updates whose ecosystem and directory were not detected, ecosystems that are never detected (e.g. helm) are kept",
+			},
+			&cli.BoolFlag{
+				Name:  "groups",
+				Usage: "add groups to new updates, those in .dependr.yml or one for minor and patch and one for major updates",
+			},
+			&cli.BoolFlag{
+				Name:  "collapse",
+				Usage: "merge new updates with the same ecosystem and settings into one using directories",
+			},
+			&cli.BoolFlag{
+				Name:  "globs",
+				Usage: "collapse new updates using a directories glob where it matches exactly the detected directories",
+			},
+			&cli.BoolFlag{
+				Name:  "reconcile",
+				Usage: "update existing entries to match the defaults in .dependr.yml and --defaults",
+			},
+			&cli.StringFlag{
+				Name:  "defaults",
+				Usage: "path to a YAML file of default settings, per ecosystem, for generated updates",
+			},
+			&cli.BoolFlag{
+				Name:  "tracked-only",
+				Usage: "only scan files tracked by git",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "write the dependabot file to a path instead of the repository, '-' for stdout",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: formatText,
+				Usage: "output format, text or json",
+			},
+		}, repoFlags()...),
 		Action: func(c *cli.Context) error {
 			path := c.Args().First()
 			create := c.Value("create-if-missing").(bool)
-
-			type scanner interface {
-				Scan() error
+			dryRun := c.Value("dry-run").(bool)
+			opts := dependabot.ScanOptions{
+				Sort:        c.Value("sort").(bool),
+				Prune:       c.Value("prune").(bool),
+				Reconcile:   c.Value("reconcile").(bool),
+				Groups:      c.Value("groups").(bool),
+				Collapse:    c.Value("collapse").(bool),
+				Globs:       c.Value("globs").(bool),
+				TrackedOnly: c.Value("tracked-only").(bool),
 			}
+
 			var s scanner
 			var err error
 
+			format := c.String("format")
+			if format != formatText && format != formatJSON {
+				return errors.Errorf("unsupported format %s", format)
+			}
+
+			output := c.String("output")
+			if output == "-" && format == formatJSON {
+				return errors.New("--output - can not be used with --format json")
+			}
+
+			if name := c.String("defaults"); name != "" {
+				if opts.Defaults, err = dependabot.LoadDefaults(name); err != nil {
+					return err
+				}
+			}
+
+			if c.String("ref") != "" && !dryRun && output == "" {
+				return errors.New("--ref is read only and requires --dry-run or --output")
+			}
+
 			if create {
-				s, err = dependabot.LoadOrCreate(path)
+				s, err = dependabot.LoadOrCreate(path, loadOptions(c)...)
 			} else {
-				s, err = dependabot.Load(path)
+				s, err = dependabot.Load(path, loadOptions(c)...)
 			}
 
 			if err != nil {
 				return errors.Wrap(err, "error loading configuration")
 			}
-			return s.Scan()
+
+			plan, err := s.Plan(opts)
+			if err != nil {
+				return err
+			}
+
+			var diff string
+			if dryRun {
+				diff, err = plan.Diff()
+				if err != nil {
+					return errors.Wrap(err, "error generating diff")
+				}
+			} else if err := write(c, s, plan, output); err != nil {
+				return err
+			}
+
+			if format == formatJSON {
+				report := plan.Report()
+				report.Diff = diff
+				encoder := json.NewEncoder(c.App.Writer)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+
+			if dryRun {
+				_, err = fmt.Fprint(c.App.Writer, diff)
+				return err
+			}
+			if output == "-" {
+				return nil
+			}
+			for _, u := range plan.Added {
+				fmt.Fprintf(c.App.Writer, "added: %s %s\n", u.PackageEcoSystem, directories(u))
+			}
+			for _, u := range plan.Pruned {
+				fmt.Fprintf(c.App.Writer, "pruned: %s %s\n", u.PackageEcoSystem, directories(u))
+			}
+			for _, ch := range plan.Reconciled {
+				fmt.Fprintf(c.App.Writer, "changed: %s %s %s: %q -> %q\n", ch.Ecosystem, ch.Directory, ch.Field, ch.From, ch.To)
+			}
+			return nil
 		},
 	}
 }
+
+type scanner interface {
+	Plan(dependabot.ScanOptions) (*dependabot.Plan, error)
+	Write(*dependabot.Plan) error
+}
+
+// write saves the plan to the repository, stdout or a file
+func write(c *cli.Context, s scanner, plan *dependabot.Plan, output string) error {
+	switch output {
+	case "":
+		return s.Write(plan)
+	case "-":
+		_, err := c.App.Writer.Write(plan.Generated)
+		return err
+	default:
+		return plan.WriteFile(output)
+	}
+}
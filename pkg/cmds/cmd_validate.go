@@ -0,0 +1,41 @@
+package cmds
+
+import (
+	"fmt"
+
+	"github.com/mdevilliers/depender/pkg/dependabot"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func validateCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "validate",
+		Usage: "validate the dependabot.yml file against the dependabot schema",
+		Flags: repoFlags(),
+		Action: func(c *cli.Context) error {
+			path := c.Args().First()
+
+			s, err := dependabot.Load(path, loadOptions(c)...)
+			if err != nil {
+				return errors.Wrap(err, "error loading configuration")
+			}
+
+			problems, err := s.Validate()
+			if err != nil {
+				return err
+			}
+
+			if len(problems) == 0 {
+				return nil
+			}
+
+			file := s.Path()
+			for _, p := range problems {
+				fmt.Fprintf(c.App.Writer, "%s:%s\n", file, p)
+			}
+
+			return errors.Errorf("%s has %d problem(s)", file, len(problems))
+		},
+	}
+}
@@ -1,12 +1,63 @@
 package cmds
 
 import (
+	"strings"
+
+	"github.com/mdevilliers/depender/pkg/dependabot"
 	"github.com/urfave/cli/v2"
 )
 
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
 // Commands returns all registered commands
 func Commands() []*cli.Command {
 	return []*cli.Command{
 		scanCmd(),
+		checkCmd(),
+		validateCmd(),
+	}
+}
+
+// repoFlags control how the repository is found and read
+func repoFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "ref",
+			Usage: "read the repository from a git branch, tag or sha instead of the working tree",
+		},
+		&cli.StringFlag{
+			Name:  "root",
+			Usage: "use this folder as the root of the repository instead of the git root",
+		},
+		&cli.BoolFlag{
+			Name:  "no-git",
+			Usage: "treat the supplied folder as the root of the repository, for folders that are not git repositories",
+		},
+	}
+}
+
+// loadOptions returns the options for loading a repository from the flags
+func loadOptions(c *cli.Context) []dependabot.LoadOption {
+	opts := []dependabot.LoadOption{}
+	if ref := c.String("ref"); ref != "" {
+		opts = append(opts, dependabot.WithRef(ref))
+	}
+	if root := c.String("root"); root != "" {
+		opts = append(opts, dependabot.WithRoot(root))
+	}
+	if c.Bool("no-git") {
+		opts = append(opts, dependabot.WithoutGit())
+	}
+	return opts
+}
+
+// directories returns the directory or comma separated directories of an update
+func directories(u dependabot.Update) string {
+	if len(u.Directories) > 0 {
+		return strings.Join(u.Directories, ",")
 	}
+	return u.Directory
 }
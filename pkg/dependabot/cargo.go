@@ -0,0 +1,99 @@
+package dependabot
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// cargoManifest holds the parts of a Cargo.toml that define a workspace
+type cargoManifest struct {
+	Package struct {
+		Workspace string `toml:"workspace"`
+	} `toml:"package"`
+	Workspace *struct {
+		Members []string `toml:"members"`
+		Exclude []string `toml:"exclude"`
+	} `toml:"workspace"`
+}
+
+// detectCargo matches Rust crates. Members of a cargo workspace share
+// the Cargo.lock in the workspace root, so a member is reported against
+// the root rather than its own directory.
+func detectCargo(p string, fsys fs.FS) ([]Match, error) {
+	dir, ok := inspected(p, "cargo")
+	if !ok {
+		return nil, nil
+	}
+
+	root, err := cargoWorkspaceRoot(fsys, cleanPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	return []Match{{Ecosystem: "cargo", Directory: path.Clean("/" + root), Manifest: p}}, nil
+}
+
+// cargoWorkspaceRoot returns the root of the workspace the crate in dir
+// is a member of, or dir if it is not a member of a workspace
+func cargoWorkspaceRoot(fsys fs.FS, dir string) (string, error) {
+	crate, err := readCargoManifest(fsys, dir)
+	if err != nil {
+		return "", err
+	}
+	if crate != nil && crate.Package.Workspace != "" {
+		return path.Join(dir, crate.Package.Workspace), nil
+	}
+	if crate != nil && crate.Workspace != nil {
+		return dir, nil
+	}
+
+	// cargo uses the nearest parent declaring a workspace
+	for parent := dir; parent != "."; {
+		parent = path.Dir(parent)
+
+		m, err := readCargoManifest(fsys, parent)
+		if err != nil {
+			return "", err
+		}
+		if m == nil || m.Workspace == nil {
+			continue
+		}
+
+		rel := dir
+		if parent != "." {
+			rel = strings.TrimPrefix(dir, parent+"/")
+		}
+		if cargoMember(m.Workspace.Members, m.Workspace.Exclude, rel) {
+			return parent, nil
+		}
+		return dir, nil
+	}
+	return dir, nil
+}
+
+// readCargoManifest returns the Cargo.toml in dir, or nil if there is
+// no Cargo.toml or it can not be parsed
+func readCargoManifest(fsys fs.FS, dir string) (*cargoManifest, error) {
+	var m cargoManifest
+	if ok, err := readManifest(fsys, path.Join(dir, "Cargo.toml"), decodeTOML(&m)); !ok {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// cargoMember returns true if the relative directory matches one of
+// the member globs and is not below an excluded path
+func cargoMember(members, exclude []string, rel string) bool {
+	for _, e := range exclude {
+		e = path.Clean(e)
+		if rel == e || strings.HasPrefix(rel, e+"/") {
+			return false
+		}
+	}
+	for _, m := range members {
+		if matchDirectory(m, rel) {
+			return true
+		}
+	}
+	return false
+}
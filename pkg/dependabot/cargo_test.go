@@ -0,0 +1,69 @@
+package dependabot
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DetectCargo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("workspace members belong to the root", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"Cargo.toml": {Data: []byte(`[workspace]
+members = ["crates/*", "tools/cli"]
+exclude = ["crates/legacy"]
+`)},
+			"Cargo.lock":               {},
+			"crates/core/Cargo.toml":   {Data: []byte("[package]\nname = \"core\"\n")},
+			"tools/cli/Cargo.toml":     {Data: []byte("[package]\nname = \"cli\"\n")},
+			"crates/legacy/Cargo.toml": {Data: []byte("[package]\nname = \"legacy\"\n")},
+			"examples/demo/Cargo.toml": {Data: []byte("[package]\nname = \"demo\"\n")},
+		}
+
+		for _, p := range []string{"Cargo.toml", "Cargo.lock", "crates/core/Cargo.toml", "tools/cli/Cargo.toml"} {
+			matches, err := detectCargo(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "cargo", Directory: "/", Manifest: p}}, matches, p)
+		}
+
+		// excluded or not listed as a member
+		for _, p := range []string{"crates/legacy/Cargo.toml", "examples/demo/Cargo.toml"} {
+			matches, err := detectCargo(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "cargo", Directory: directoryOf(p), Manifest: p}}, matches, p)
+		}
+	})
+
+	t.Run("package naming its workspace", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"nested/Cargo.toml":     {Data: []byte("[package]\nname = \"nested\"\n\n[workspace]\n")},
+			"nested/sub/Cargo.toml": {Data: []byte("[package]\nname = \"sub\"\nworkspace = \"..\"\n")},
+		}
+
+		for _, p := range []string{"nested/Cargo.toml", "nested/sub/Cargo.toml"} {
+			matches, err := detectCargo(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "cargo", Directory: "/nested", Manifest: p}}, matches, p)
+		}
+	})
+
+	t.Run("unparsable crate is still a member", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"Cargo.toml":               {Data: []byte("[workspace]\nmembers = [\"crates/*\"]\n")},
+			"crates/broken/Cargo.toml": {Data: []byte("[package\n")},
+		}
+
+		matches, err := detectCargo("crates/broken/Cargo.toml", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "cargo", Directory: "/", Manifest: "crates/broken/Cargo.toml"}}, matches)
+	})
+}
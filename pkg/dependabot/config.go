@@ -0,0 +1,271 @@
+package dependabot
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Config is the per repository configuration for dependr, read
+	// from a .dependr.yml file in the root of the repository
+	Config struct {
+		// Ecosystems adds rules mapping files to ecosystems
+		Ecosystems []EcosystemRule `yaml:"ecosystems"`
+		// Disable turns off detection for an ecosystem (e.g. docker) or
+		// for the files matching a pattern (e.g. Dockerfile.*)
+		Disable []string `yaml:"disable"`
+		// Exclude lists the directories that are not scanned. A pattern
+		// without a '/' matches a directory name at any depth (e.g. testdata)
+		// otherwise it matches the path from the root (e.g. examples/*).
+		Exclude []string `yaml:"exclude"`
+		// Groups are added to generated updates when grouping is enabled
+		Groups map[string]Group `yaml:"groups"`
+		// Defaults are applied to every generated update, replacing
+		// any value from the shared defaults
+		Defaults Update `yaml:"defaults"`
+
+		// shared holds the defaults shared across repositories
+		shared *Defaults
+	}
+
+	// EcosystemRule maps files matching the pattern to an ecosystem,
+	// patterns follow the same rules as the built in ones
+	EcosystemRule struct {
+		Pattern   string `yaml:"pattern"`
+		Ecosystem string `yaml:"ecosystem"`
+	}
+
+	// disabledDetector drops matches disabled by the configuration
+	disabledDetector struct {
+		detector Detector
+		disable  []string
+	}
+)
+
+var configFiles = []string{".dependr.yml", ".dependr.yaml"}
+
+// loadConfig reads the dependr configuration from the root of the
+// repository, returning an empty configuration if there is none.
+func loadConfig(fsys fs.FS) (Config, error) {
+	var c Config
+
+	fileName := findFirst(fsys, configFiles)
+	if fileName == "" {
+		return c, nil
+	}
+
+	data, err := fs.ReadFile(fsys, fileName)
+	if err != nil {
+		return c, errors.Wrapf(err, "error loading file: %s", fileName)
+	}
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, errors.Wrapf(err, "error loading: %s", fileName)
+	}
+
+	for i, r := range c.Ecosystems {
+		if r.Pattern == "" || r.Ecosystem == "" {
+			return c, errors.Errorf("error loading: %s, ecosystems require a pattern and an ecosystem", fileName)
+		}
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return c, errors.Wrapf(err, "error loading: %s, invalid pattern %s", fileName, r.Pattern)
+		}
+		ecosystem, ok := LookupEcosystem(r.Ecosystem)
+		if !ok {
+			return c, errors.Errorf("error loading: %s, unknown ecosystem %s", fileName, r.Ecosystem)
+		}
+		c.Ecosystems[i].Ecosystem = ecosystem.Name
+	}
+
+	for i, d := range c.Disable {
+		if ecosystem, ok := LookupEcosystem(d); ok {
+			c.Disable[i] = ecosystem.Name
+		}
+	}
+	return c, nil
+}
+
+// detectors returns the detectors to use once the configuration is applied
+func (c Config) detectors(base *Detectors) *Detectors {
+	d := &Detectors{}
+	if len(c.Disable) > 0 {
+		d.Register(disabledDetector{detector: base, disable: c.Disable})
+	} else {
+		d.Register(base)
+	}
+
+	if len(c.Ecosystems) > 0 {
+		rules := ecosystems{}
+		for _, r := range c.Ecosystems {
+			rules.rules = append(rules.rules, rule{pattern: r.Pattern, ecosystem: r.Ecosystem})
+		}
+		d.Register(rules)
+	}
+	return d
+}
+
+// detectable returns true if the ecosystem has built in manifest patterns
+// or is named by a rule in the configuration
+func (c Config) detectable(ecosystem string) bool {
+	e, ok := LookupEcosystem(ecosystem)
+	if !ok {
+		return false
+	}
+	if len(e.Manifests) > 0 || len(e.Inspected) > 0 {
+		return true
+	}
+	for _, r := range c.Ecosystems {
+		if r.Ecosystem == e.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded returns true if the slash separated directory should not be scanned
+func (c Config) excluded(dir string) bool {
+	for _, pattern := range c.Exclude {
+		pattern = strings.Trim(pattern, "/")
+		target := path.Base(dir)
+		if strings.Contains(pattern, "/") {
+			target = dir
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newUpdate returns a default update for the ecosystem and directory
+// with the configured defaults applied
+func (c Config) newUpdate(ecosystem, directory string) Update {
+	return newDefaultUpdate(ecosystem, directory).overlay(c.policy(ecosystem))
+}
+
+// policy returns the configured settings for updates of the ecosystem,
+// the repository defaults take precedence over the shared ones
+func (c Config) policy(ecosystem string) Update {
+	return c.shared.template(ecosystem).overlay(c.Defaults)
+}
+
+// groups returns the configured groups or groups for minor and patch
+// updates and for major updates
+func (c Config) groups() map[string]Group {
+	if len(c.Groups) > 0 {
+		return c.Groups
+	}
+	return map[string]Group{
+		"minor-and-patch": {Patterns: []string{"*"}, UpdateTypes: []string{"minor", "patch"}},
+		"major":           {Patterns: []string{"*"}, UpdateTypes: []string{"major"}},
+	}
+}
+
+// Detect drops any matches that have been disabled
+func (d disabledDetector) Detect(p string, fsys fs.FS) ([]Match, error) {
+	matches, err := d.detector.Detect(p, fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := []Match{}
+	for _, m := range matches {
+		if !d.disabled(m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
+
+// disabled returns true if the ecosystem or manifest is disabled
+func (d disabledDetector) disabled(m Match) bool {
+	for _, pattern := range d.disable {
+		if pattern == m.Ecosystem {
+			return true
+		}
+		if _, ok := (rule{pattern: pattern}).match(m.Manifest); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// overlay returns a copy of the update with any values set in o
+// replacing those in u. Package ecosystem and directories are never
+// replaced.
+func (u Update) overlay(o Update) Update { //nolint:gocyclo
+	if o.Schedule.Interval != "" {
+		u.Schedule.Interval = o.Schedule.Interval
+		// a day is only valid for weekly updates, a cronjob for cron
+		if o.Schedule.Interval != "weekly" {
+			u.Schedule.Day = ""
+		}
+		if o.Schedule.Interval != "cron" {
+			u.Schedule.Cronjob = ""
+		}
+	}
+	if o.Schedule.Day != "" {
+		u.Schedule.Day = o.Schedule.Day
+	}
+	if o.Schedule.Time != "" {
+		u.Schedule.Time = o.Schedule.Time
+	}
+	if o.Schedule.Timezone != "" {
+		u.Schedule.Timezone = o.Schedule.Timezone
+	}
+	if o.Schedule.Cronjob != "" {
+		u.Schedule.Cronjob = o.Schedule.Cronjob
+	}
+	if o.Allow != nil {
+		u.Allow = o.Allow
+	}
+	if o.Assignees != nil {
+		u.Assignees = o.Assignees
+	}
+	if o.CommitMessage != nil {
+		u.CommitMessage = o.CommitMessage
+	}
+	if o.Groups != nil {
+		u.Groups = o.Groups
+	}
+	if o.Ignore != nil {
+		u.Ignore = o.Ignore
+	}
+	if o.InsecureExternalCodeExecution != "" {
+		u.InsecureExternalCodeExecution = o.InsecureExternalCodeExecution
+	}
+	if o.Labels != nil {
+		u.Labels = o.Labels
+	}
+	if o.Milestone != 0 {
+		u.Milestone = o.Milestone
+	}
+	if o.OpenPullRequestsLimit != nil {
+		u.OpenPullRequestsLimit = o.OpenPullRequestsLimit
+	}
+	if o.PullRequestBranchName != nil {
+		u.PullRequestBranchName = o.PullRequestBranchName
+	}
+	if o.RebaseStrategy != "" {
+		u.RebaseStrategy = o.RebaseStrategy
+	}
+	if o.Registries != nil {
+		u.Registries = o.Registries
+	}
+	if o.Reviewers != nil {
+		u.Reviewers = o.Reviewers
+	}
+	if o.TargetBranch != "" {
+		u.TargetBranch = o.TargetBranch
+	}
+	if o.Vendor {
+		u.Vendor = o.Vendor
+	}
+	if o.VersioningStrategy != "" {
+		u.VersioningStrategy = o.VersioningStrategy
+	}
+	return u
+}
@@ -0,0 +1,99 @@
+package dependabot
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadConfig(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		".dependr.yml": {Data: []byte(`ecosystems:
+  - pattern: "*.tf"
+    ecosystem: terraform
+disable:
+  - elm
+exclude:
+  - testdata
+defaults:
+  schedule:
+    interval: daily
+  labels:
+    - dependencies
+`)},
+	}
+
+	c, err := loadConfig(fsys)
+
+	require.Nil(t, err)
+	require.Equal(t, []EcosystemRule{{Pattern: "*.tf", Ecosystem: "terraform"}}, c.Ecosystems)
+	require.Equal(t, []string{"elm"}, c.Disable)
+	require.Equal(t, []string{"testdata"}, c.Exclude)
+
+	u := c.newUpdate("gomod", "/")
+	require.Equal(t, "daily", u.Schedule.Interval)
+	require.Equal(t, []string{"dependencies"}, u.Labels)
+}
+
+func Test_LoadConfig_Missing(t *testing.T) {
+	t.Parallel()
+
+	c, err := loadConfig(fstest.MapFS{})
+
+	require.Nil(t, err)
+	require.Equal(t, "weekly", c.newUpdate("gomod", "/").Schedule.Interval)
+}
+
+func Test_Config_Detectors(t *testing.T) {
+	t.Parallel()
+
+	c := Config{
+		Ecosystems: []EcosystemRule{{Pattern: "*.tf", Ecosystem: "terraform"}},
+		Disable:    []string{"elm", "Dockerfile.*"},
+	}
+	d := c.detectors(NewDetectors())
+	fsys := fstest.MapFS{}
+
+	tests := []struct {
+		path  string
+		found bool
+	}{
+		{path: "infra/main.tf", found: true},
+		{path: "elm-package.json", found: false},
+		{path: "deploy/Dockerfile.prod", found: false},
+		{path: "deploy/Dockerfile", found: true},
+	}
+
+	for _, tt := range tests {
+		matches, err := d.Detect(tt.path, fsys)
+		require.Nil(t, err)
+		require.Equal(t, tt.found, len(matches) > 0, tt.path)
+	}
+}
+
+func Test_Config_Excluded(t *testing.T) {
+	t.Parallel()
+
+	c := Config{Exclude: []string{"testdata", "/examples/*"}}
+
+	require.True(t, c.excluded("testdata"))
+	require.True(t, c.excluded("pkg/foo/testdata"))
+	require.True(t, c.excluded("examples/hello"))
+	require.False(t, c.excluded("examples"))
+	require.False(t, c.excluded("pkg/examples/hello"))
+}
+
+func Test_LoadConfig_Unknown_Ecosystem(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		".dependr.yml": {Data: []byte("ecosystems:\n  - pattern: build.gradle\n    ecosystem: gradel\n")},
+	}
+
+	_, err := loadConfig(fsys)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unknown ecosystem gradel")
+}
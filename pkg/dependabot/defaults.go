@@ -0,0 +1,79 @@
+package dependabot
+
+import (
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults are templates for generated updates shared across
+// repositories, for example:
+//
+//	default:
+//	  schedule:
+//	    interval: weekly
+//	ecosystems:
+//	  gomod:
+//	    schedule:
+//	      interval: daily
+//	    labels: [go]
+type Defaults struct {
+	// Default is applied to updates for every ecosystem
+	Default Update `yaml:"default"`
+	// Ecosystems are applied to the updates for the named ecosystem,
+	// replacing any value set in Default
+	Ecosystems map[string]Update `yaml:"ecosystems"`
+}
+
+// LoadDefaults reads a defaults file from disk
+func LoadDefaults(name string) (*Defaults, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading file: %s", name)
+	}
+
+	d := &Defaults{}
+	if err := yaml.Unmarshal(data, d); err != nil {
+		return nil, errors.Wrapf(err, "error loading: %s", name)
+	}
+
+	// templates may use an alias e.g. yarn for npm
+	templates := map[string]Update{}
+	unknown := []string{}
+	duplicated := map[string]bool{}
+	for key, template := range d.Ecosystems {
+		ecosystem, ok := LookupEcosystem(key)
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		if _, found := templates[ecosystem.Name]; found {
+			duplicated[ecosystem.Name] = true
+		}
+		templates[ecosystem.Name] = template
+	}
+	duplicates := []string{}
+	for ecosystem := range duplicated {
+		duplicates = append(duplicates, ecosystem)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, errors.Errorf("error loading: %s, unknown ecosystems %v", name, unknown)
+	}
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return nil, errors.Errorf("error loading: %s, ecosystems defined more than once %v", name, duplicates)
+	}
+	d.Ecosystems = templates
+	return d, nil
+}
+
+// template returns the settings for updates of the ecosystem
+func (d *Defaults) template(ecosystem string) Update {
+	if d == nil {
+		return Update{}
+	}
+	return d.Default.overlay(d.Ecosystems[ecosystem])
+}
@@ -0,0 +1,101 @@
+package dependabot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadDefaults(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "defaults.yml")
+	require.Nil(t, os.WriteFile(name, []byte(`default:
+  schedule:
+    interval: weekly
+    day: monday
+  labels: [dependencies]
+ecosystems:
+  gomod:
+    schedule:
+      interval: daily
+    labels: [go]
+  docker:
+    schedule:
+      interval: monthly
+  github-actions:
+    commit-message:
+      prefix: ci
+`), 0600))
+
+	d, err := LoadDefaults(name)
+	require.Nil(t, err)
+
+	gomod := d.template("gomod")
+	require.Equal(t, Schedule{Interval: "daily"}, gomod.Schedule)
+	require.Equal(t, []string{"go"}, gomod.Labels)
+
+	actions := d.template("github-actions")
+	require.Equal(t, Schedule{Interval: "weekly", Day: "monday"}, actions.Schedule)
+	require.Equal(t, "ci", actions.CommitMessage.Prefix)
+	require.Equal(t, []string{"dependencies"}, actions.Labels)
+}
+
+func Test_LoadDefaults_Unknown_Ecosystem(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "defaults.yml")
+	require.Nil(t, os.WriteFile(name, []byte("ecosystems:\n  gradel: {}\n"), 0600))
+
+	_, err := LoadDefaults(name)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "gradel")
+}
+
+func Test_Plan_Applies_Defaults(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"go.mod":       {Data: []byte("module foo\n")},
+		"Dockerfile":   {Data: []byte("FROM scratch\n")},
+		".dependr.yml": {Data: []byte("defaults:\n  labels: [deps]\n")},
+	}
+	n, err := LoadOrCreateFS(fsys, ".")
+	require.Nil(t, err)
+
+	plan, err := n.Plan(ScanOptions{Defaults: &Defaults{
+		Ecosystems: map[string]Update{
+			"gomod":  {Schedule: Schedule{Interval: "daily"}, Labels: []string{"go"}},
+			"docker": {Schedule: Schedule{Interval: "monthly"}},
+		},
+	}})
+	require.Nil(t, err)
+
+	require.Len(t, plan.Added, 2)
+	docker, gomod := plan.Added[0], plan.Added[1]
+	require.Equal(t, "monthly", docker.Schedule.Interval)
+	require.Equal(t, []string{"deps"}, docker.Labels)
+	// the repository defaults take precedence
+	require.Equal(t, "daily", gomod.Schedule.Interval)
+	require.Equal(t, []string{"deps"}, gomod.Labels)
+}
+
+func Test_LoadDefaults_Duplicate_Ecosystem(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "defaults.yml")
+	require.Nil(t, os.WriteFile(name, []byte(`ecosystems:
+  npm: {labels: [js]}
+  yarn: {labels: [yarn]}
+  gomod: {}
+  go: {}
+  golang: {}
+`), 0600))
+
+	_, err := LoadDefaults(name)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "ecosystems defined more than once [gomod npm]")
+}
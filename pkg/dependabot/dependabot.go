@@ -1,8 +1,11 @@
 package dependabot
 
 import (
+	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -15,8 +18,37 @@ type (
 		repo repo
 	}
 
+	// LoadOption configures how a repository is loaded from disk
+	LoadOption func(*loadOptions)
+
+	loadOptions struct {
+		// ref is the git ref to read the repository from, the working
+		// tree is used if empty
+		ref string
+		// root overrides the discovered root of the repository
+		root string
+		// noGit treats the supplied folder as the root of the repository
+		noGit bool
+	}
+
+	// NotARepositoryError is returned when a folder is not in a git repository
+	NotARepositoryError struct {
+		Path string
+	}
+
+	// InvalidGitFileError is returned when a .git file does not point
+	// to a git directory e.g. a broken worktree or submodule
+	InvalidGitFileError struct {
+		Path   string
+		Reason string
+	}
+
 	repo struct {
-		// root is the absolute path to the folder containing filePath
+		// fsys is the file system holding the repository, its root is the
+		// root of the repository
+		fsys fs.FS
+		// root is the absolute path to the repository on disk, empty if
+		// the repository is not on disk
 		root string
 		// dependabotFilePath is the local path (from the root) to the dependabot configuration
 		dependabotFilePath   string
@@ -26,23 +58,57 @@ type (
 
 var (
 	ErrMissingConfigFile = errors.New("error finding dependabot config")
+	ErrReadOnly          = errors.New("file system is read only")
+	ErrNotOnDisk         = errors.New("repository is not on disk")
 
-	// allow redirecting os functions for testing
-	osReadFile  = os.ReadFile
-	osStat      = os.Stat
-	osGetwd     = os.Getwd
-	osWriteFile = os.WriteFile
-	osMkdirAll  = os.MkdirAll
-
-	// allow redirecting the git command for testing
-	osGetRootFolder = getRootFolder
+	// dependabotFiles are the supported dependabot file names in order of preference
+	dependabotFiles = []string{
+		"dependabot.yml", "dependabot.yaml", ".github/dependabot.yml", ".github/dependabot.yaml",
+	}
 )
 
 // Load will search the path for a dependabot file, returning an
 // initialsed parsed config or an error
-func Load(path string) (*node, error) {
+func Load(path string, opts ...LoadOption) (*node, error) {
+	return loadFromDisk(path, false, opts)
+}
 
-	repo, err := newRepo(path, false)
+// LoadOrCreate will find and load an existing dependabotconfig
+// or promise to create one if missing and a config is required.
+// The path must path to a folder that exists.
+func LoadOrCreate(path string, opts ...LoadOption) (*node, error) {
+	return loadFromDisk(path, true, opts)
+}
+
+// WithRoot uses dir as the root of the repository rather than
+// discovering it, the path being loaded must be inside dir
+func WithRoot(dir string) LoadOption {
+	return func(o *loadOptions) {
+		o.root = dir
+	}
+}
+
+// WithoutGit treats the folder being loaded (or the folder holding the
+// file being loaded) as the root, for folders that are not git repositories
+func WithoutGit() LoadOption {
+	return func(o *loadOptions) {
+		o.noGit = true
+	}
+}
+
+// WithRef reads the repository from a git ref (a branch, tag or sha)
+// rather than the working tree. The repository is read only.
+func WithRef(ref string) LoadOption {
+	return func(o *loadOptions) {
+		o.ref = ref
+	}
+}
+
+// LoadFS is the same as Load for a repository held in a file system.
+// The path is slash separated and relative to the root of fsys, which
+// is the root of the repository.
+func LoadFS(fsys fs.FS, path string) (*node, error) {
+	repo, err := newRepo(fsys, path, false)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error parsing supplied path %s", path)
 	}
@@ -51,60 +117,118 @@ func Load(path string) (*node, error) {
 	}, nil
 }
 
-// LoadOrCreate will find and load an existing dependabotconfig
-// or promise to create one if missing and a config is required.
-// The path must path to a folder that exists.
-func LoadOrCreate(path string) (*node, error) {
+// LoadOrCreateFS is the same as LoadOrCreate for a repository held in a
+// file system. The file system must be a WriteFS for the file to be created.
+func LoadOrCreateFS(fsys fs.FS, path string) (*node, error) {
+	repo, err := newRepo(fsys, path, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing supplied path %s", path)
+	}
+	return &node{
+		repo: *repo,
+	}, nil
+}
+
+// loadFromDisk resolves the git repository holding path and loads
+// the repository from disk
+func loadFromDisk(path string, createIfMissing bool, opts []LoadOption) (*node, error) {
+
+	options := loadOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	fullpath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing supplied path %s", path)
+	}
+
+	dir := fullpath
+	fi, err := os.Stat(fullpath)
+	switch {
+	case err == nil:
+		if !fi.IsDir() {
+			dir = filepath.Dir(fullpath)
+		}
+	case options.ref != "" && errors.Is(err, fs.ErrNotExist):
+		// the file may only exist in the ref
+		dir = filepath.Dir(fullpath)
+	default:
+		return nil, errors.Wrapf(err, "error parsing supplied path %s", path)
+	}
 
-	repo, err := newRepo(path, true)
+	root, err := options.resolveRoot(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error resolving to a github repo - %s", fullpath)
+	}
+
+	rel, err := filepath.Rel(root, fullpath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, errors.Errorf("error resolving to a github repo - %s is not inside %s", fullpath, root)
+	}
+
+	var fsys fs.FS = DirFS(root)
+	if options.ref != "" {
+		fsys, err = GitFS(root, options.ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	repo, err := newRepo(fsys, filepath.ToSlash(rel), createIfMissing)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error parsing supplied path %s", path)
 	}
+	repo.root = root
+
 	return &node{
 		repo: *repo,
 	}, nil
 }
 
+// resolveRoot returns the root of the repository holding dir
+func (o loadOptions) resolveRoot(dir string) (string, error) {
+	switch {
+	case o.noGit && o.ref != "":
+		return "", errors.New("a git ref can not be read without git")
+	case o.root != "":
+		return filepath.Abs(o.root)
+	case o.noGit:
+		return dir, nil
+	}
+	return getRootFolder(dir)
+}
+
+// Path returns the full path to the dependabot file
+func (n *node) Path() string {
+	return filepath.Join(n.repo.root, filepath.FromSlash(n.repo.dependabotFilePath))
+}
+
 // A repo encapsulates all of the file path information for
 // a github repository.
-func newRepo(path string, createIfMissing bool) (*repo, error) {
+func newRepo(fsys fs.FS, p string, createIfMissing bool) (*repo, error) {
 
-	fullpath, err := ensureAbsolutePath(path)
-	if err != nil {
-		return nil, err
-	}
-	ret := &repo{}
+	p = cleanPath(p)
+	ret := &repo{fsys: fsys}
 
-	fi, err := osStat(fullpath)
+	fi, err := fs.Stat(fsys, p)
 	if err != nil {
 		return nil, err
 	}
 
 	var fileName string
 
-	files := []string{
-		"dependabot.yml", "dependabot.yaml", ".github/dependabot.yml", ".github/dependabot.yaml",
-	}
-
 	switch mode := fi.Mode(); {
 	case mode.IsRegular():
-		ret.root, err = osGetRootFolder(filepath.Dir(fullpath))
-		if err != nil {
-			return nil, errors.Wrapf(err, "error resolving to a github repo - %s", fullpath)
-		}
 		// is the file a whitelisted dependabot file
-		fileName = isADependabotFile(fi.Name(), files)
+		fileName = isADependabotFile(p, dependabotFiles)
 		if fileName == "" {
 			return ret, ErrMissingConfigFile
 		}
 
 	case mode.IsDir():
-		ret.root, err = osGetRootFolder(fullpath)
-		if err != nil {
-			return nil, errors.Wrapf(err, "error resolving to a github repo - %s", fullpath)
-		}
 		// look for dependabot files from root
-		fileName = findDependabotFile(ret.root, files)
+		fileName = findFirst(fsys, dependabotFiles)
 		if fileName == "" {
 			if createIfMissing {
 				ret.dependabotFileExists = false
@@ -124,10 +248,10 @@ func newRepo(path string, createIfMissing bool) (*repo, error) {
 	return ret, nil
 }
 
-// returns a matched file if it exists or an empty string
-func findDependabotFile(root string, files []string) string {
-	for _, f := range files {
-		if pathExists(filepath.Join(root, f)) {
+// findFirst returns the first of the candidates that exists or an empty string
+func findFirst(fsys fs.FS, candidates []string) string {
+	for _, f := range candidates {
+		if pathExists(fsys, f) {
 			return f
 		}
 	}
@@ -146,33 +270,151 @@ func isADependabotFile(fileName string, files []string) string {
 }
 
 // pathExists return true if exists
-func pathExists(path string) bool {
-	if _, err := osStat(path); errors.Is(err, os.ErrNotExist) {
+func pathExists(fsys fs.FS, p string) bool {
+	if _, err := fs.Stat(fsys, cleanPath(p)); errors.Is(err, fs.ErrNotExist) {
 		return false
 	}
 	return true
 }
 
-// ensureAbsolutePath makes the path absolute or returns an error
-func ensureAbsolutePath(path string) (string, error) {
-	if !filepath.IsAbs(path) {
-		wd, err := osGetwd()
-		if err != nil {
+// cleanPath converts a path relative to the root, or a dependabot
+// directory e.g. '/foo/', into a valid fs.FS path e.g. 'foo'
+func cleanPath(p string) string {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// getRootFolder returns the path to the 'root' folder, the first folder
+// from dir upwards holding a .git folder, or a .git file as used by
+// worktrees and submodules.
+func getRootFolder(dir string) (string, error) {
+	for current := dir; ; {
+		gitPath := filepath.Join(current, ".git")
+		fi, err := os.Stat(gitPath)
+
+		switch {
+		case err == nil && fi.IsDir():
+			return current, nil
+		case err == nil:
+			if _, err := readGitFile(gitPath); err != nil {
+				return "", err
+			}
+			return current, nil
+		case !errors.Is(err, fs.ErrNotExist):
 			return "", err
 		}
-		return filepath.Join(wd, path), nil
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", &NotARepositoryError{Path: dir}
+		}
+		current = parent
 	}
-	return path, nil
 }
 
-// getRootFolder returns the path to the 'root' folder or an error
-func getRootFolder(dir string) (string, error) {
-	path, err := getCommandOutput(dir, "git", "rev-parse", "--show-toplevel")
+// readGitFile returns the git directory a .git file points to
+// e.g. 'gitdir: ../.git/worktrees/foo'
+func readGitFile(gitPath string) (string, error) {
+	data, err := os.ReadFile(gitPath)
+	if err != nil {
+		return "", err
+	}
+
+	content := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(content, "gitdir:") {
+		return "", &InvalidGitFileError{Path: gitPath, Reason: "missing gitdir"}
+	}
+
+	gitdir := filepath.FromSlash(strings.TrimSpace(strings.TrimPrefix(content, "gitdir:")))
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(filepath.Dir(gitPath), gitdir)
+	}
+	if fi, err := os.Stat(gitdir); err != nil || !fi.IsDir() {
+		return "", &InvalidGitFileError{Path: gitPath, Reason: fmt.Sprintf("gitdir %s does not exist", gitdir)}
+	}
+	return gitdir, nil
+}
+
+// getCommonGitDir returns the git directory of the repository at root,
+// following a .git file and, for a worktree, the commondir shared with
+// the main repository. An empty string is returned if there is no .git.
+func getCommonGitDir(root string) (string, error) {
+	gitPath := filepath.Join(root, ".git")
+	fi, err := os.Stat(gitPath)
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
 		return "", err
 	}
-	bits := strings.Split(path, "\n")
-	return bits[0], nil
+
+	dir := gitPath
+	if !fi.IsDir() {
+		if dir, err = readGitFile(gitPath); err != nil {
+			return "", err
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "commondir")); err == nil {
+		common := filepath.FromSlash(strings.TrimSpace(string(data)))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(dir, common)
+		}
+		dir = common
+	}
+	return dir, nil
+}
+
+func (e *NotARepositoryError) Error() string {
+	return fmt.Sprintf("not a git repository (or any of the parent directories): %s", e.Path)
+}
+
+func (e *InvalidGitFileError) Error() string {
+	return fmt.Sprintf("invalid git file %s: %s", e.Path, e.Reason)
+}
+
+// getGlobalExcludesFile returns the path to the users global git ignore
+// file for the repository in dir, following the same rules as git.
+func getGlobalExcludesFile(dir string) string {
+	if _, err := exec.LookPath("git"); err == nil {
+		// run in the repository so its own core.excludesFile is used
+		path, err := getCommandOutput(dir, "git", "config", "--path", "--get", "core.excludesFile")
+		if err == nil && path != "" {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			return path
+		}
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "git", "ignore")
+}
+
+// getTrackedFiles returns the slash separated paths, relative to the
+// root, of all of the files tracked by git
+func getTrackedFiles(root string) (map[string]bool, error) {
+	e := exec.Command("git", "ls-files", "-z")
+	e.Dir = root
+	data, err := e.Output()
+	if err != nil {
+		return nil, err
+	}
+	tracked := map[string]bool{}
+	for _, f := range strings.Split(string(data), "\x00") {
+		if f != "" {
+			tracked[f] = true
+		}
+	}
+	return tracked, nil
 }
 
 // getCommandOutput evaluates the given command and returns the trimmed output
@@ -3,163 +3,525 @@ package dependabot
 import (
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 
-	"github.com/mdevilliers/depender/pkg/dependabot/dependabotfakes"
-	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
-//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
-//counterfeiter:generate os.FileInfo
+// memFS is a writable fstest.MapFS
+type memFS struct {
+	fstest.MapFS
+}
 
-func Test_Load_Dependabot_File_Exists(t *testing.T) {
+func (m memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.MapFS[name] = &fstest.MapFile{Data: data, Mode: perm}
+	return nil
+}
 
-	osGetRootFolder = func(string) (string, error) {
-		return "/user/foo", nil
-	}
-	osGetwd = func() (string, error) {
-		return "/user/", nil
-	}
-	osStat = func(name string) (os.FileInfo, error) {
-		f := &dependabotfakes.FakeFileInfo{}
-		f.ModeReturns(fs.ModeAppend) // file
-		f.NameReturns("dependabot.yml")
+func (m memFS) MkdirAll(name string, perm fs.FileMode) error {
+	return nil
+}
+
+func Test_Load_Dependabot_File_Exists(t *testing.T) {
+	t.Parallel()
 
-		return f, nil
+	fsys := fstest.MapFS{
+		"dependabot.yml": {},
 	}
 
-	n, err := Load("./foo/dependabot.yml")
+	n, err := LoadFS(fsys, "./dependabot.yml")
 
 	require.Nil(t, err)
 	require.True(t, n.repo.dependabotFileExists)
 	require.Equal(t, "dependabot.yml", n.repo.dependabotFilePath)
-	require.Equal(t, "/user/foo", n.repo.root)
 }
 
 func Test_Load_Dependabot_File_Not_Exists(t *testing.T) {
+	t.Parallel()
 
-	osGetRootFolder = func(string) (string, error) {
-		return "/user/foo", nil
-	}
-	osGetwd = func() (string, error) {
-		return "/user/", nil
-	}
-	osStat = func(name string) (os.FileInfo, error) {
-		return nil, os.ErrNotExist
-	}
-
-	_, err := Load("./foo/dependabot.yml")
+	_, err := LoadFS(fstest.MapFS{}, "./dependabot.yml")
 
 	require.NotNil(t, err)
 }
 
 func Test_LoadOrCreate_Dependabot_File_Exists(t *testing.T) {
+	t.Parallel()
 
-	osGetRootFolder = func(string) (string, error) {
-		return "/user/foo", nil
+	fsys := fstest.MapFS{
+		".github/dependabot.yml": {},
 	}
-	osGetwd = func() (string, error) {
-		return "/user/", nil
-	}
-	osStat = func(name string) (os.FileInfo, error) {
-		f := &dependabotfakes.FakeFileInfo{}
-		f.ModeReturns(fs.ModeAppend) // file
-		f.NameReturns("dependabot.yml")
 
-		return f, nil
-	}
-
-	n, err := LoadOrCreate("./foo/dependabot.yml")
+	n, err := LoadOrCreateFS(fsys, ".github/dependabot.yml")
 
 	require.Nil(t, err)
 	require.True(t, n.repo.dependabotFileExists)
-	require.Equal(t, "dependabot.yml", n.repo.dependabotFilePath)
-	require.Equal(t, "/user/foo", n.repo.root)
+	require.Equal(t, ".github/dependabot.yml", n.repo.dependabotFilePath)
 }
 
 func Test_LoadOrCreate_Dependabot_Folder_Not_Exists(t *testing.T) {
+	t.Parallel()
 
-	osGetRootFolder = func(string) (string, error) {
-		return "/user/foo", nil
-	}
-	osGetwd = func() (string, error) {
-		return "/user/", nil
-	}
-	osStat = func(name string) (os.FileInfo, error) {
-		if name == "/user/foo" {
-			f := &dependabotfakes.FakeFileInfo{}
-			f.ModeReturns(fs.ModeDir)
-			f.NameReturns("foo")
-			return f, nil
-		}
-		return nil, os.ErrNotExist
+	fsys := fstest.MapFS{
+		"go.mod": {},
 	}
 
-	n, err := LoadOrCreate("./foo/")
+	n, err := LoadOrCreateFS(fsys, "./")
 
 	require.Nil(t, err)
 	require.False(t, n.repo.dependabotFileExists)
 	require.Equal(t, ".github/dependabot.yml", n.repo.dependabotFilePath)
-	require.Equal(t, "/user/foo", n.repo.root)
 }
 
 func Test_Load_Not_A_GitRepo(t *testing.T) {
-	osGetRootFolder = func(string) (string, error) {
-		return "", errors.New("booyah!")
-	}
-	_, err := Load("./foo/dependabot.yml")
+	t.Parallel()
+
+	_, err := Load(t.TempDir())
+
+	var notARepo *NotARepositoryError
+	require.ErrorAs(t, err, &notARepo)
+}
 
+func Test_Load_Finds_Git_Folder(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(root, ".git"), 0700))
+	require.Nil(t, os.MkdirAll(filepath.Join(root, "svc", "api"), 0700))
+	require.Nil(t, os.WriteFile(filepath.Join(root, "dependabot.yml"), []byte{}, 0600))
+
+	n, err := Load(filepath.Join(root, "svc", "api"))
+
+	require.Nil(t, err)
+	require.Equal(t, root, n.repo.root)
+	require.Equal(t, "dependabot.yml", n.repo.dependabotFilePath)
+}
+
+func Test_Load_Finds_Git_File(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	worktree := filepath.Join(root, "worktree")
+	require.Nil(t, os.MkdirAll(filepath.Join(root, "main", ".git", "worktrees", "wt"), 0700))
+	require.Nil(t, os.MkdirAll(worktree, 0700))
+	require.Nil(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: ../main/.git/worktrees/wt\n"), 0600))
+
+	n, err := LoadOrCreate(worktree)
+
+	require.Nil(t, err)
+	require.Equal(t, worktree, n.repo.root)
+}
+
+func Test_Load_Invalid_Git_File(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(root, ".git"), []byte("gitdir: ./missing\n"), 0600))
+
+	_, err := LoadOrCreate(root)
+
+	var invalid *InvalidGitFileError
+	require.ErrorAs(t, err, &invalid)
+}
+
+func Test_Load_Without_Git(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	n, err := LoadOrCreate(root, WithoutGit())
+
+	require.Nil(t, err)
+	require.Equal(t, root, n.repo.root)
+}
+
+func Test_Load_With_Root(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(root, "svc"), 0700))
+
+	n, err := LoadOrCreate(filepath.Join(root, "svc"), WithRoot(root))
+	require.Nil(t, err)
+	require.Equal(t, root, n.repo.root)
+
+	_, err = LoadOrCreate(root, WithRoot(filepath.Join(root, "svc")))
 	require.NotNil(t, err)
 }
 
-func Test_Load_Dependabot_Folder_Exists(t *testing.T) {
+func Test_Load_Not_A_Dependabot_File(t *testing.T) {
+	t.Parallel()
 
-	osGetRootFolder = func(string) (string, error) {
-		return "/user/foo", nil
-	}
-	osGetwd = func() (string, error) {
-		return "/user/", nil
+	fsys := fstest.MapFS{
+		"foo/dependabot.yml": {},
 	}
 
-	osStat = func(name string) (os.FileInfo, error) {
-		if name == "/user/foo" {
-			f := &dependabotfakes.FakeFileInfo{}
-			f.ModeReturns(fs.ModeDir)
-			f.NameReturns("foo")
-
-			return f, nil
-		}
-		if name == "/user/foo/.github/dependabot.yml" {
-			f := &dependabotfakes.FakeFileInfo{}
-			f.ModeReturns(fs.ModeAppend)
-			f.NameReturns(".github/dependabot.yml")
-			return f, nil
-		}
-		return nil, os.ErrNotExist
+	_, err := LoadFS(fsys, "foo/dependabot.yml")
+
+	require.ErrorIs(t, err, ErrMissingConfigFile)
+}
+
+func Test_Load_Dependabot_Folder_Exists(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"go.mod":                 {},
+		".github/dependabot.yml": {},
 	}
 
-	n, err := Load("./foo/")
+	n, err := LoadFS(fsys, ".")
 
 	require.Nil(t, err)
 	require.True(t, n.repo.dependabotFileExists)
 	require.Equal(t, ".github/dependabot.yml", n.repo.dependabotFilePath)
-	require.Equal(t, "/user/foo", n.repo.root)
 }
 
 func Test_Load_Dependabot_Folder_Not_Exists(t *testing.T) {
+	t.Parallel()
 
-	osGetRootFolder = func(string) (string, error) {
-		return "/user/foo", nil
+	fsys := fstest.MapFS{
+		"go.mod": {},
 	}
-	osGetwd = func() (string, error) {
-		return "/user/", nil
+
+	_, err := LoadFS(fsys, ".")
+
+	require.ErrorIs(t, err, ErrMissingConfigFile)
+}
+
+func Test_Scan_Creates_Dependabot_File(t *testing.T) {
+	t.Parallel()
+
+	fsys := memFS{fstest.MapFS{
+		".gitignore":                      {Data: []byte("testdata/\n")},
+		".github/workflows/ci.yml":        {},
+		"go.mod":                          {},
+		"web/package.json":                {},
+		"web/node_modules/x/package.json": {},
+		"testdata/go.mod":                 {},
+	}}
+
+	n, err := LoadOrCreateFS(fsys, ".")
+	require.Nil(t, err)
+	require.Nil(t, n.Scan(ScanOptions{}))
+
+	generated := string(fsys.MapFS[".github/dependabot.yml"].Data)
+	require.Contains(t, generated, `updates:
+  - package-ecosystem: github-actions
+    directory: /
+    schedule:
+      interval: weekly
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: weekly
+  - package-ecosystem: npm
+    directory: /web
+    schedule:
+      interval: weekly
+`)
+
+	// scanning again changes nothing
+	n, err = LoadFS(fsys, ".")
+	require.Nil(t, err)
+	require.Nil(t, n.Scan(ScanOptions{}))
+	require.Equal(t, generated, string(fsys.MapFS[".github/dependabot.yml"].Data))
+}
+
+func Test_Plan_Empty_Dependabot_File(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		".github/dependabot.yml": {Data: []byte{}},
 	}
-	osStat = func(name string) (os.FileInfo, error) {
-		return nil, os.ErrNotExist
+
+	n, err := LoadFS(fsys, ".")
+	require.Nil(t, err)
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+	require.Nil(t, plan.Generated)
+
+	fsys["go.mod"] = &fstest.MapFile{}
+
+	plan, err = n.Plan(ScanOptions{})
+	require.Nil(t, err)
+	require.Equal(t, `version: 2
+updates:
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: weekly
+`, string(plan.Generated))
+}
+
+func Test_Scan_Read_Only(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"go.mod": {},
 	}
 
-	_, err := Load("./foo/")
+	n, err := LoadOrCreateFS(fsys, ".")
+	require.Nil(t, err)
+	require.ErrorIs(t, n.Scan(ScanOptions{}), ErrReadOnly)
+}
 
-	require.NotNil(t, err)
+func Test_Plan_Diff_New_File(t *testing.T) {
+
+	p := &Plan{
+		Path:      ".github/dependabot.yml",
+		Exists:    false,
+		Generated: []byte("version: 2\nupdates: []\n"),
+	}
+
+	diff, err := p.Diff()
+
+	require.Nil(t, err)
+	require.Equal(t, `--- /dev/null
++++ b/.github/dependabot.yml
+@@ -0,0 +1,2 @@
++version: 2
++updates: []
+`, diff)
+}
+
+func Test_Plan_Diff_Keeps_Indentation(t *testing.T) {
+	t.Parallel()
+
+	for name, indent := range map[string]string{"two spaces": "  ", "four spaces": "    "} {
+		indent := indent
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			original := strings.ReplaceAll(`version: 2
+updates:
+>- package-ecosystem: gomod
+>  directory: /
+>  schedule:
+>    interval: weekly
+`, ">", indent)
+			fsys := fstest.MapFS{
+				".github/dependabot.yml": {Data: []byte(original)},
+				"go.mod":                 {},
+				"web/package.json":       {},
+			}
+
+			n, err := LoadFS(fsys, ".")
+			require.Nil(t, err)
+			plan, err := n.Plan(ScanOptions{})
+			require.Nil(t, err)
+
+			require.Equal(t, original+strings.ReplaceAll(`>- package-ecosystem: npm
+>  directory: /web
+>  schedule:
+>    interval: weekly
+`, ">", indent), string(plan.Generated))
+
+			diff, err := plan.Diff()
+			require.Nil(t, err)
+			require.NotContains(t, diff, "\n-")
+		})
+	}
+}
+
+func Test_Plan_Diff_Nothing_To_Write(t *testing.T) {
+
+	p := &Plan{
+		Path:     ".github/dependabot.yml",
+		Exists:   true,
+		Original: []byte("version: 2\n"),
+	}
+
+	diff, err := p.Diff()
+
+	require.Nil(t, err)
+	require.Empty(t, diff)
+}
+
+func Test_Plan_Report(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		".github/dependabot.yml": {Data: []byte(`version: 2
+updates:
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: weekly
+  - package-ecosystem: npm
+    directory: /old
+    schedule:
+      interval: weekly
+`)},
+		"go.mod":           {},
+		"go.sum":           {},
+		"web/package.json": {},
+	}
+
+	n, err := LoadFS(fsys, ".")
+	require.Nil(t, err)
+	plan, err := n.Plan(ScanOptions{Prune: true})
+	require.Nil(t, err)
+
+	report := plan.Report()
+
+	require.True(t, report.Existed)
+	require.True(t, report.Changed)
+	require.Equal(t, []Match{
+		{Ecosystem: "gomod", Directory: "/", Manifest: "go.mod"},
+		{Ecosystem: "gomod", Directory: "/", Manifest: "go.sum"},
+		{Ecosystem: "npm", Directory: "/web", Manifest: "web/package.json"},
+	}, report.Detected)
+	require.Equal(t, []ReportUpdate{{Ecosystem: "gomod", Directory: "/"}}, report.Present)
+	require.Equal(t, []ReportUpdate{{Ecosystem: "npm", Directory: "/web"}}, report.Added)
+	require.Equal(t, []ReportUpdate{{Ecosystem: "npm", Directory: "/old"}}, report.Pruned)
+}
+
+func Test_Plan_Report_Formatting_Is_Not_A_Change(t *testing.T) {
+	t.Parallel()
+
+	original := `version: 2
+updates:
+  - package-ecosystem: npm
+    directory: "/web"
+    schedule: {interval: weekly}
+  - package-ecosystem:   gomod
+    directory: /
+    schedule:
+      interval: weekly
+`
+	fsys := memFS{fstest.MapFS{
+		".github/dependabot.yml": {Data: []byte(original)},
+		"go.mod":                 {},
+		"web/package.json":       {},
+	}}
+
+	n, err := LoadFS(fsys, ".")
+	require.Nil(t, err)
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+	require.NotEqual(t, original, string(plan.Generated))
+	require.False(t, plan.Report().Changed)
+	diff, err := plan.Diff()
+	require.Nil(t, err)
+	require.Empty(t, diff)
+
+	// the existing file is left alone
+	require.Nil(t, n.Write(plan))
+	require.Equal(t, original, string(fsys.MapFS[".github/dependabot.yml"].Data))
+
+	plan, err = n.Plan(ScanOptions{Sort: true})
+	require.Nil(t, err)
+	require.True(t, plan.Sorted)
+	require.True(t, plan.Report().Changed)
+}
+
+func Test_Scan_Preserves_File_Mode(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dependabotFile := filepath.Join(root, "dependabot.yml")
+	require.Nil(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte{}, 0600))
+	require.Nil(t, os.WriteFile(dependabotFile, []byte("version: 2\nupdates:\n"), 0600))
+	require.Nil(t, os.Chmod(dependabotFile, 0640))
+
+	n, err := Load(root, WithoutGit())
+	require.Nil(t, err)
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+
+	require.Nil(t, n.Write(plan))
+	fi, err := os.Stat(dependabotFile)
+	require.Nil(t, err)
+	require.Equal(t, fs.FileMode(0640), fi.Mode().Perm())
+
+	output := filepath.Join(t.TempDir(), "out.yml")
+	require.Nil(t, plan.WriteFile(output))
+	fi, err = os.Stat(output)
+	require.Nil(t, err)
+	require.Equal(t, fs.FileMode(0640), fi.Mode().Perm())
+
+	data, err := os.ReadFile(output)
+	require.Nil(t, err)
+	require.Equal(t, plan.Generated, data)
+}
+
+func Test_Scan_Groups_And_Collapses(t *testing.T) {
+	t.Parallel()
+
+	fsys := memFS{fstest.MapFS{
+		"go.mod":               {},
+		"services/a/go.mod":    {},
+		"services/b/go.mod":    {},
+		"web/package.json":     {},
+		"tools/gen/Cargo.toml": {},
+	}}
+	opts := ScanOptions{Groups: true, Collapse: true}
+
+	n, err := LoadOrCreateFS(fsys, ".")
+	require.Nil(t, err)
+	require.Nil(t, n.Scan(opts))
+
+	generated := string(fsys.MapFS[".github/dependabot.yml"].Data)
+	require.Contains(t, generated, `  - package-ecosystem: gomod
+    directories:
+      - /
+      - /services/a
+      - /services/b
+    schedule:
+      interval: weekly
+    groups:
+      major:
+        patterns:
+          - '*'
+        update-types:
+          - major
+      minor-and-patch:
+        patterns:
+          - '*'
+        update-types:
+          - minor
+          - patch
+`)
+	require.Contains(t, generated, `  - package-ecosystem: npm
+    directory: /web
+`)
+
+	// the collapsed directories are not added again
+	n, err = LoadFS(fsys, ".")
+	require.Nil(t, err)
+	plan, err := n.Plan(opts)
+	require.Nil(t, err)
+	require.Empty(t, plan.Added)
+	require.Len(t, plan.Present, 5)
+	require.Equal(t, generated, string(plan.Generated))
+}
+
+func Test_Plan_Git_File_Reads_Common_Info_Exclude(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.Nil(t, os.MkdirAll(filepath.Dir(p), 0700))
+		require.Nil(t, os.WriteFile(p, []byte(content), 0600))
+	}
+	write("main/.git/info/exclude", "build/\nexamples/\n")
+	write("main/.git/worktrees/wt/commondir", "../..\n")
+	write("wt/.git", "gitdir: ../main/.git/worktrees/wt\n")
+	write("wt/go.mod", "")
+	write("wt/build/go.mod", "")
+	write("wt/examples/go.mod", "")
+	// .gitignore takes precedence over info/exclude
+	write("wt/.gitignore", "!examples/\n")
+
+	n, err := LoadOrCreate(filepath.Join(dir, "wt"))
+	require.Nil(t, err)
+
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+	require.Len(t, plan.Added, 2)
+	require.Equal(t, "/", plan.Added[0].Directory)
+	require.Equal(t, "/examples", plan.Added[1].Directory)
 }
@@ -0,0 +1,185 @@
+package dependabot
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Detector reports the ecosystems a file belongs to. Detect is called
+	// for every file in the repository with the slash separated path of the
+	// file relative to the root of fsys.
+	Detector interface {
+		Detect(path string, fsys fs.FS) ([]Match, error)
+	}
+
+	// DetectorFunc allows a function to be used as a Detector
+	DetectorFunc func(path string, fsys fs.FS) ([]Match, error)
+
+	// Match is a package ecosystem found in a directory
+	Match struct {
+		Ecosystem string `json:"ecosystem"`
+		// Directory is the dependabot directory e.g. '/' or '/services/api'
+		Directory string `json:"directory"`
+		// Manifest is the path to the file that was detected
+		Manifest string `json:"manifest"`
+		// Vendor is true if the dependencies are vendored
+		Vendor bool `json:"vendor,omitempty"`
+	}
+
+	// Detectors holds the detectors used to scan a repository
+	Detectors struct {
+		detectors []Detector
+	}
+)
+
+// Detect calls f(path, fsys)
+func (f DetectorFunc) Detect(path string, fsys fs.FS) ([]Match, error) {
+	return f(path, fsys)
+}
+
+// NewDetectors returns the built in detectors
+func NewDetectors() *Detectors {
+	return &Detectors{
+		detectors: []Detector{
+			wellKnown,
+			DetectorFunc(detectGithubActions),
+			DetectorFunc(detectPyProject),
+			DetectorFunc(detectSetupCfg),
+			DetectorFunc(detectJavaScript),
+			DetectorFunc(detectGo),
+			DetectorFunc(detectCargo),
+			DetectorFunc(detectGradle),
+			DetectorFunc(detectMaven),
+		},
+	}
+}
+
+// Register adds detectors to those used when scanning. Matches must name
+// an ecosystem dependabot supports, or one of its aliases, otherwise
+// scanning fails as the update could never be written.
+func (r *Detectors) Register(detectors ...Detector) {
+	r.detectors = append(r.detectors, detectors...)
+}
+
+// Detect returns the matches from all of the registered detectors
+func (r *Detectors) Detect(path string, fsys fs.FS) ([]Match, error) {
+	all := []Match{}
+	for _, d := range r.detectors {
+		matches, err := d.Detect(path, fsys)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error detecting ecosystem for %s", path)
+		}
+		all = append(all, matches...)
+	}
+	return all, nil
+}
+
+// Detect matches the file against the rules
+func (e ecosystems) Detect(path string, _ fs.FS) ([]Match, error) {
+	if ecosystem, dir, found := e.match(path); found {
+		return []Match{{Ecosystem: ecosystem, Directory: dir, Manifest: path}}, nil
+	}
+	return nil, nil
+}
+
+// detectGithubActions finds workflows in the root .github folder
+func detectGithubActions(p string, _ fs.FS) ([]Match, error) {
+	if dir, ok := inspected(p, "github-actions"); !ok || dir != "/" {
+		return nil, nil
+	}
+	return []Match{{Ecosystem: "github-actions", Directory: "/", Manifest: p}}, nil
+}
+
+// detectPyProject only treats a pyproject.toml as a manifest if it
+// declares a project, rather than just configuring tools e.g. black.
+// Poetry and PEP 621 projects are pip, those managed by uv are uv.
+func detectPyProject(p string, fsys fs.FS) ([]Match, error) {
+	if path.Base(p) != "pyproject.toml" {
+		return nil, nil
+	}
+
+	var project struct {
+		Project map[string]interface{} `toml:"project"`
+		Tool    struct {
+			Poetry map[string]interface{} `toml:"poetry"`
+			UV     map[string]interface{} `toml:"uv"`
+		} `toml:"tool"`
+	}
+	if ok, err := readManifest(fsys, p, decodeTOML(&project)); !ok {
+		return nil, err
+	}
+
+	ecosystem := "pip"
+	switch {
+	case project.Tool.Poetry != nil:
+		// dependabot updates poetry projects with pip
+	case project.Tool.UV != nil, project.Project != nil && pathExists(fsys, path.Join(path.Dir(p), "uv.lock")):
+		ecosystem = "uv"
+	case project.Project == nil:
+		return nil, nil
+	}
+	return []Match{{Ecosystem: ecosystem, Directory: directoryOf(p), Manifest: p}}, nil
+}
+
+// detectSetupCfg only treats a setup.cfg as a pip manifest if it
+// describes a package, rather than just configuring tools e.g. flake8.
+func detectSetupCfg(p string, fsys fs.FS) ([]Match, error) {
+	if path.Base(p) != "setup.cfg" {
+		return nil, nil
+	}
+
+	data, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, err
+	}
+
+	section := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "metadata" {
+				return []Match{{Ecosystem: "pip", Directory: directoryOf(p), Manifest: p}}, nil
+			}
+			continue
+		}
+		key, _, found := strings.Cut(line, "=")
+		if found && section == "options" && strings.TrimSpace(key) == "install_requires" {
+			return []Match{{Ecosystem: "pip", Directory: directoryOf(p), Manifest: p}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// directoryOf returns the dependabot directory for a file
+func directoryOf(p string) string {
+	return path.Clean("/" + path.Dir(p))
+}
+
+// readManifest reads the file at p, if it exists, with decode returning
+// true if it was decoded. A manifest that can not be decoded is treated
+// as missing rather than failing the scan, dependabot could not parse
+// it either.
+func readManifest(fsys fs.FS, p string, decode func(data []byte) error) (bool, error) {
+	if !pathExists(fsys, p) {
+		return false, nil
+	}
+	data, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return false, errors.Wrapf(err, "error loading file: %s", p)
+	}
+	return decode(data) == nil, nil
+}
+
+// decodeTOML returns a decoder for readManifest filling v
+func decodeTOML(v interface{}) func(data []byte) error {
+	return func(data []byte) error {
+		_, err := toml.Decode(string(data), v)
+		return err
+	}
+}
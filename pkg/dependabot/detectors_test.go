@@ -0,0 +1,125 @@
+package dependabot
+
+import (
+	"io/fs"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Detectors_Builtin(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"go.mod":                      {},
+		".github/workflows/ci.yml":    {},
+		"api/.github/workflows/x.yml": {},
+		"app/pyproject.toml": {Data: []byte(`[project]
+name = "app"
+`)},
+		"tools/pyproject.toml": {Data: []byte(`[tool.black]
+line-length = 88
+`)},
+		"poetry/pyproject.toml": {Data: []byte(`[tool.poetry]
+name = "poetry"
+`)},
+		"uv/pyproject.toml": {Data: []byte(`[project]
+name = "uv"
+
+[tool.uv]
+dev-dependencies = []
+`)},
+		"locked/pyproject.toml": {Data: []byte(`[project]
+name = "locked"
+`)},
+		"locked/uv.lock":        {},
+		"broken/pyproject.toml": {Data: []byte("[project\n")},
+		"lib/setup.cfg": {Data: []byte(`[options]
+packages = find:
+install_requires =
+    requests
+`)},
+		"meta/setup.cfg": {Data: []byte(`[metadata]
+name = meta
+`)},
+		"lint/setup.cfg": {Data: []byte(`[flake8]
+max-line-length = 100
+
+[tool:pytest]
+addopts = -q
+`)},
+	}
+
+	tests := []struct {
+		path    string
+		matches []Match
+	}{
+		{path: "go.mod", matches: []Match{{Ecosystem: "gomod", Directory: "/", Manifest: "go.mod"}}},
+		{path: ".github/workflows/ci.yml", matches: []Match{{Ecosystem: "github-actions", Directory: "/", Manifest: ".github/workflows/ci.yml"}}},
+		{path: "api/.github/workflows/x.yml", matches: []Match{}},
+		{path: "app/pyproject.toml", matches: []Match{{Ecosystem: "pip", Directory: "/app", Manifest: "app/pyproject.toml"}}},
+		{path: "tools/pyproject.toml", matches: []Match{}},
+		{path: "poetry/pyproject.toml", matches: []Match{{Ecosystem: "pip", Directory: "/poetry", Manifest: "poetry/pyproject.toml"}}},
+		{path: "uv/pyproject.toml", matches: []Match{{Ecosystem: "uv", Directory: "/uv", Manifest: "uv/pyproject.toml"}}},
+		{path: "locked/pyproject.toml", matches: []Match{{Ecosystem: "uv", Directory: "/locked", Manifest: "locked/pyproject.toml"}}},
+		{path: "broken/pyproject.toml", matches: []Match{}},
+		{path: "lib/setup.cfg", matches: []Match{{Ecosystem: "pip", Directory: "/lib", Manifest: "lib/setup.cfg"}}},
+		{path: "meta/setup.cfg", matches: []Match{{Ecosystem: "pip", Directory: "/meta", Manifest: "meta/setup.cfg"}}},
+		{path: "lint/setup.cfg", matches: []Match{}},
+	}
+
+	d := NewDetectors()
+	for _, tt := range tests {
+		matches, err := d.Detect(tt.path, fsys)
+		require.Nil(t, err, tt.path)
+		require.Equal(t, tt.matches, matches, tt.path)
+	}
+}
+
+func Test_Detectors_Register(t *testing.T) {
+	t.Parallel()
+
+	d := NewDetectors()
+	d.Register(DetectorFunc(func(p string, fsys fs.FS) ([]Match, error) {
+		if path.Base(p) == "Chart.yaml" {
+			return []Match{{Ecosystem: "helm", Directory: directoryOf(p), Manifest: p}}, nil
+		}
+		return nil, nil
+	}))
+
+	fsys := fstest.MapFS{
+		"charts/app/Chart.yaml": {},
+	}
+
+	matches, err := d.Detect("charts/app/Chart.yaml", fsys)
+	require.Nil(t, err)
+	require.Equal(t, []Match{{Ecosystem: "helm", Directory: "/charts/app", Manifest: "charts/app/Chart.yaml"}}, matches)
+
+	n, err := LoadOrCreateFS(fsys, ".")
+	require.Nil(t, err)
+	plan, err := n.Plan(ScanOptions{Detectors: d})
+	require.Nil(t, err)
+	require.Len(t, plan.Added, 1)
+	require.Equal(t, "helm", plan.Added[0].PackageEcoSystem)
+	require.Equal(t, "/charts/app", plan.Added[0].Directory)
+}
+
+func Test_Detectors_Register_Unknown_Ecosystem(t *testing.T) {
+	t.Parallel()
+
+	d := NewDetectors()
+	d.Register(DetectorFunc(func(p string, fsys fs.FS) ([]Match, error) {
+		if p == "flake.nix" {
+			return []Match{{Ecosystem: "nix", Directory: "/", Manifest: p}}, nil
+		}
+		return nil, nil
+	}))
+
+	n, err := LoadOrCreateFS(fstest.MapFS{"flake.nix": {}}, ".")
+	require.Nil(t, err)
+	_, err = n.Plan(ScanOptions{Detectors: d})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unknown ecosystem nix")
+}
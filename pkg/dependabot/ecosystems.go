@@ -1,160 +1,379 @@
 package dependabot
 
 import (
-	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/mdevilliers/depender/pkg/ignore"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 )
 
 type (
 	ecosystems struct {
-		files map[string]string
+		rules []rule
 	}
 
-	Doc struct {
-		Updates []Update
-	}
-	Update struct {
-		PackageEcoSystem string   `yaml:"package-ecosystem"`
-		Directory        string   `yaml:"directory"`
-		Schedule         Schedule `yaml:"schedule"`
-	}
-	Schedule struct {
-		Interval string
+	// rule maps files matching pattern to an ecosystem. The pattern is
+	// matched using path.Match so can be an exact file name (go.mod),
+	// an extension (*.csproj) or a glob (Dockerfile.*). A pattern containing
+	// a '/' matches the trailing path e.g. requirements/*.txt and the update
+	// directory is the folder the pattern starts in.
+	rule struct {
+		pattern   string
+		ecosystem string
 	}
 
 	Updates map[string]Update
 )
 
 var (
-	//nolint:lll
-	// https://docs.github.com/en/code-security/dependabot/dependabot-version-updates/configuration-options-for-the-dependabot.yml-file#package-ecosystem
-	// https://docs.github.com/en/code-security/supply-chain-security/understanding-your-software-supply-chain/about-the-dependency-graph#supported-package-ecosystems
-	wellKnown = ecosystems{
-		files: map[string]string{
-			"Gemfile.lock":        "bundler",
-			"Gemfile":             "bundler", // TODO: support *.gemspec
-			"Cargo.toml":          "cargo",
-			"Cargo.lock":          "cargo",
-			"composer.json":       "composer",
-			"composer.lock":       "composer",
-			"Dockerfile":          "docker", // TODO: support 'artisinal' dockerfile names
-			"mix.exs":             "hex",
-			"elm-package.json":    "elm",
-			".gitmodules":         "gitsubmodule",
-			"go.mod":              "gomod",
-			"go.sum":              "gomod",
-			"build.gradle":        "gradel",
-			"pom.xml":             "maven",
-			"package-lock.json":   "npm",
-			"package.json":        "npm",
-			"yarn.lock":           "npm",
-			".csproj":             "nuget",
-			".vbproj":             "nuget",
-			".nuspec":             "nuget",
-			".vcxproj":            "nuget",
-			".fsproj":             "nuget",
-			"packages.config":     "nuget",
-			"requirements.txt":    "pip",
-			"pipfile":             "pip",
-			"pipfile.lock":        "pip",
-			"setup.py":            "pip",
-			".terraform.lock.hcl": "terraform",
-		},
-	}
+	// the permissions for a new dependabot file
+	defaultFileMode fs.FileMode = 0600
+
+	// files holding gitignore style patterns for paths not to scan
+	ignoreFiles = []string{".gitignore", ".dependrignore"}
 )
 
-func (n *node) Scan() error { //nolint:funlen
+// match returns the ecosystem and update directory for the first rule
+// matching the slash separated path relative to the repository root
+func (e ecosystems) match(rel string) (string, string, bool) {
+	for _, r := range e.rules {
+		if dir, ok := r.match(rel); ok {
+			return r.ecosystem, dir, true
+		}
+	}
+	return "", "", false
+}
+
+// match returns the update directory if the path matches the rule
+func (r rule) match(rel string) (string, bool) {
+	segments := strings.Split(rel, "/")
+	depth := strings.Count(r.pattern, "/") + 1
+	if depth > len(segments) {
+		return "", false
+	}
+
+	tail := strings.Join(segments[len(segments)-depth:], "/")
+	if ok, _ := path.Match(r.pattern, tail); !ok {
+		return "", false
+	}
+	return "/" + strings.Join(segments[:len(segments)-depth], "/"), true
+}
+
+// Scan walks the repository looking for well known package manifests
+// and writes any missing update entries to the dependabot file.
+func (n *node) Scan(opts ScanOptions) error {
+
+	plan, err := n.Plan(opts)
+	if err != nil {
+		return err
+	}
+	return n.Write(plan)
+}
+
+// Write saves the generated dependabot file from a Plan
+func (n *node) Write(plan *Plan) error {
+
+	// nothing to do, leaving the formatting of an existing file alone
+	if plan.Generated == nil || (plan.Exists && !plan.Changed()) {
+		return nil
+	}
+
+	w, ok := n.repo.fsys.(WriteFS)
+	if !ok {
+		return ErrReadOnly
+	}
+
+	// ensure directory exists
+	dir := path.Dir(n.repo.dependabotFilePath)
+	if !pathExists(w, dir) {
+		if err := w.MkdirAll(dir, os.ModePerm); err != nil {
+			return errors.Wrapf(err, "error creating folder : %s", dir)
+		}
+	}
+
+	if err := w.WriteFile(n.repo.dependabotFilePath, plan.Generated, plan.Mode); err != nil {
+		return errors.Wrapf(err, "error writing dependabot file: %s", n.Path())
+	}
+
+	return nil
+}
+
+// detect walks the repository returning an update for every
+// ecosystem and directory found
+func (n *node) detect(config Config, opts ScanOptions) (Updates, []Match, error) { //nolint:funlen,gocyclo
 
 	updates := Updates{}
+	detected := []Match{}
+	fsys := n.repo.fsys
+
+	detectors := opts.Detectors
+	if detectors == nil {
+		detectors = NewDetectors()
+	}
+	detectors = config.detectors(detectors)
+
+	ignored, err := n.loadIgnored()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// everything in a git tree is tracked
+	_, isGitTree := fsys.(*gitFS)
+
+	var tracked map[string]bool
+	if opts.TrackedOnly && !isGitTree {
+		if n.repo.root == "" {
+			return nil, nil, errors.Wrap(ErrNotOnDisk, "error listing tracked files")
+		}
+		tracked, err = getTrackedFiles(n.repo.root)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error listing tracked files")
+		}
+	}
 
 	// walk the file system looking for well known files
 	// append updates as required
-	err := filepath.Walk(n.repo.root,
-		func(path string, info os.FileInfo, err error) error {
+	err = fs.WalkDir(fsys, ".",
+		func(rel string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
-			if strings.Contains(path, "node_modules") {
+			if d.Name() == ".git" {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
 				return nil
 			}
-			if strings.Contains(path, ".git/") || strings.HasSuffix(path, ".git") {
+
+			if d.IsDir() {
+				if rel != "." && (d.Name() == "node_modules" || config.excluded(rel) || ignored.Match(rel, true)) {
+					return fs.SkipDir
+				}
+				return addIgnoreFiles(fsys, ignored, rel)
+			}
+
+			if ignored.Match(rel, false) {
 				return nil
 			}
-			if info.IsDir() {
+			if tracked != nil && !tracked[rel] {
 				return nil
 			}
 
-			dot := func(i string) string {
-				if i == "." {
-					return "/"
-				}
-				return fmt.Sprintf("/%s", i)
+			matches, err := detectors.Detect(rel, fsys)
+			if err != nil {
+				return err
 			}
+			for _, m := range matches {
+				// only ecosystems dependabot supports are ever written
+				ecosystem, ok := LookupEcosystem(m.Ecosystem)
+				if !ok {
+					return errors.Errorf("unknown ecosystem %s detected for %s", m.Ecosystem, rel)
+				}
+				if ecosystem.RootOnly && m.Directory != "/" {
+					continue
+				}
+				m.Ecosystem = ecosystem.Name
+				m.Vendor = m.Vendor && ecosystem.Vendor
 
-			v, found := wellKnown.files[info.Name()]
-			if found {
-				rel, err := filepath.Rel(n.repo.root, path)
-				if err != nil {
-					return err
+				u := config.newUpdate(m.Ecosystem, m.Directory)
+				if m.Vendor {
+					u.Vendor = true
 				}
-				rel = filepath.Dir(rel)
-				update := newDefaultUpdate(v, dot(rel))
-				updates.Add(update)
+				if opts.Groups && u.Groups == nil {
+					u.Groups = config.groups()
+				}
+				updates.Add(u)
+				detected = append(detected, m)
 			}
 
 			return nil
 		})
 
 	if err != nil {
-		return errors.Wrap(err, "error iterating root folder")
+		return nil, nil, errors.Wrap(err, "error iterating root folder")
+	}
+	return updates, detected, nil
+}
+
+// loadIgnored returns a matcher holding the users global git excludes,
+// if the repository is on disk, and the excludes for the repository.
+// As the last matching pattern wins they are added in the same order
+// as git, with the .gitignore files added later during the walk.
+func (n *node) loadIgnored() (*ignore.Matcher, error) {
+	ignored := ignore.New()
+
+	if n.repo.root != "" {
+		if f := getGlobalExcludesFile(n.repo.root); f != "" {
+			data, err := os.ReadFile(f)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, errors.Wrapf(err, "error loading file: %s", f)
+			}
+			ignored.Add("", data)
+		}
+	}
+
+	data, err := n.readInfoExclude()
+	if err != nil {
+		return nil, err
+	}
+	ignored.Add("", data)
+	return ignored, nil
+}
+
+// readInfoExclude returns the info/exclude file of the repository. On
+// disk a .git file is followed to the git directory, otherwise only a
+// .git directory in the file system is read.
+func (n *node) readInfoExclude() ([]byte, error) {
+	var name string
+	var data []byte
+	var err error
+
+	if n.repo.root != "" {
+		dir, gitErr := getCommonGitDir(n.repo.root)
+		if gitErr != nil {
+			return nil, errors.Wrap(gitErr, "error finding git directory")
+		}
+		if dir == "" {
+			return nil, nil
+		}
+		name = filepath.Join(dir, "info", "exclude")
+		data, err = os.ReadFile(name)
+	} else {
+		if fi, statErr := fs.Stat(n.repo.fsys, ".git"); statErr != nil || !fi.IsDir() {
+			return nil, nil
+		}
+		name = ".git/info/exclude"
+		data, err = fs.ReadFile(n.repo.fsys, name)
+	}
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.Wrapf(err, "error loading file: %s", name)
+	}
+	return data, nil
+}
+
+// addIgnoreFiles adds the patterns from any .gitignore or .dependrignore
+// files found in the folder
+func addIgnoreFiles(fsys fs.FS, ignored *ignore.Matcher, dir string) error {
+	for _, name := range ignoreFiles {
+		p := path.Join(dir, name)
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return errors.Wrapf(err, "error loading file: %s", p)
+		}
+		ignored.Add(dir, data)
+	}
+	return nil
+}
+
+// Plan runs the same detection as Scan returning the resulting
+// dependabot file without writing anything to disk.
+func (n *node) Plan(opts ScanOptions) (*Plan, error) { //nolint:funlen
+
+	config, err := loadConfig(n.repo.fsys)
+	if err != nil {
+		return nil, err
 	}
+	config.shared = opts.Defaults
 
-	// check for github actions
-	githubActionsPath := filepath.Join(n.repo.root, ".github/workflows/")
-	if pathExists(githubActionsPath) {
-		update := newDefaultUpdate("github-actions", "/")
-		updates.Add(update)
+	updates, detected, err := n.detect(config, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		Root:     n.repo.root,
+		Path:     n.repo.dependabotFilePath,
+		Exists:   n.repo.dependabotFileExists,
+		Mode:     defaultFileMode,
+		Detected: detected,
 	}
 
 	var p yaml.Node
+	indent := defaultIndent
 
 	if n.repo.dependabotFileExists {
-		data, err := osReadFile(path.Join(n.repo.root, n.repo.dependabotFilePath))
+		data, err := fs.ReadFile(n.repo.fsys, n.repo.dependabotFilePath)
 		if err != nil {
-			return errors.Wrapf(err, "error loading file: %s", n.repo.dependabotFilePath)
+			return nil, errors.Wrapf(err, "error loading file: %s", n.repo.dependabotFilePath)
+		}
+		plan.Original = data
+		indent = indentation(data)
+
+		// only a file on disk has permissions worth keeping
+		if _, isGitTree := n.repo.fsys.(*gitFS); n.repo.root != "" && !isGitTree {
+			if fi, err := fs.Stat(n.repo.fsys, n.repo.dependabotFilePath); err == nil {
+				plan.Mode = fi.Mode().Perm()
+			}
 		}
+
 		if err := yaml.Unmarshal(data, &p); err != nil {
-			return errors.Wrapf(err, "error loading: %s", n.repo.dependabotFilePath)
+			return nil, errors.Wrapf(err, "error loading: %s", n.repo.dependabotFilePath)
 		}
 
 		// load existing file in to a Doc instance (loosing comments)
 		var doc Doc
 		if err := yaml.Unmarshal(data, &doc); err != nil {
-			return errors.Wrapf(err, "error loading: %s", n.repo.dependabotFilePath)
+			return nil, errors.Wrapf(err, "error loading: %s", n.repo.dependabotFilePath)
+		}
+
+		if opts.Prune {
+			plan.Pruned = pruneUpdates(&p, updates, func(ecosystem string) bool {
+				// a registered detector may find ecosystems the built in ones do not
+				return config.detectable(ecosystem) || detectedEcosystem(detected, ecosystem)
+			})
+		}
+
+		if opts.Reconcile {
+			plan.Reconciled = reconcileUpdates(&p, config.policy)
 		}
 
 		// iterate through Doc.Updates removing duplicates
+		all := updates.ToArray()
 		for _, u := range doc.Updates {
 			updates.RemoveIfExists(u)
 		}
 
+		for _, u := range all {
+			if !updates.Contains(u) {
+				plan.Present = append(plan.Present, u)
+			}
+		}
+		if updates, err = n.consolidate(updates, opts); err != nil {
+			return nil, err
+		}
+		plan.Added = updates.ToArray()
+
 		// append what is left...
 		if err = updates.ApplyAllTo(&p); err != nil {
-			return errors.Wrap(err, "error applying updates to existing files")
+			return nil, errors.Wrap(err, "error applying updates to existing files")
+		}
+
+		// an empty file with nothing to add is left alone
+		if len(p.Content) == 0 {
+			return plan, nil
 		}
 	} else {
 
 		// check if we need to do anything, return if nothing to do.
 		if updates.Empty() {
-			return nil
+			return plan, nil
 		}
 
+		if updates, err = n.consolidate(updates, opts); err != nil {
+			return nil, err
+		}
+		plan.Added = updates.ToArray()
+
 		//nolint:lll
 		data := `# To get started with Dependabot version updates, you'll need to specify which
 # package ecosystems to update and where the package manifests are located.
@@ -165,34 +384,52 @@ version: 2
 updates:
 `
 		if err := yaml.Unmarshal([]byte(data), &p); err != nil {
-			return errors.Wrapf(err, "error loading: %s", n.repo.dependabotFilePath)
+			return nil, errors.Wrapf(err, "error loading: %s", n.repo.dependabotFilePath)
 		}
 
 		if err = updates.ApplyAllTo(&p); err != nil {
-			return errors.Wrap(err, "error applying updates to a new file")
+			return nil, errors.Wrap(err, "error applying updates to a new file")
 		}
 	}
 
-	bytes, err := yaml.Marshal(&p)
+	if opts.Sort {
+		plan.Sorted = sortUpdates(&p)
+	}
+
+	bytes, err := encode(&p, indent)
 	if err != nil {
-		return errors.Wrap(err, "error marshalling yaml")
+		return nil, errors.Wrap(err, "error marshalling yaml")
 	}
+	plan.Generated = bytes
 
-	fullPath := filepath.Join(n.repo.root, n.repo.dependabotFilePath)
+	return plan, nil
+}
 
-	// ensure directory exists
-	dir := path.Dir(fullPath)
-	if !pathExists(dir) {
-		if err := osMkdirAll(dir, os.ModePerm); err != nil {
-			return errors.Wrapf(err, "error creating folder : %s", dir)
+// detectedEcosystem returns true if any of the matches are for the ecosystem
+func detectedEcosystem(detected []Match, ecosystem string) bool {
+	e, _ := LookupEcosystem(ecosystem)
+	for _, m := range detected {
+		if m.Ecosystem == e.Name {
+			return true
 		}
 	}
+	return false
+}
 
-	if err := osWriteFile(fullPath, bytes, 0600); err != nil { //nolint:gomnd
-		return errors.Wrapf(err, "error writing dependabot file: %s", fullPath)
+// consolidate collapses and globs the updates as requested by the options
+func (n *node) consolidate(updates Updates, opts ScanOptions) (Updates, error) {
+	var err error
+	if opts.Collapse || opts.Globs {
+		if updates, err = updates.Collapse(); err != nil {
+			return nil, errors.Wrap(err, "error collapsing updates")
+		}
 	}
-
-	return nil
+	if opts.Globs {
+		if updates, err = updates.Globs(n.repo.fsys); err != nil {
+			return nil, errors.Wrap(err, "error globbing directories")
+		}
+	}
+	return updates, nil
 }
 
 func newDefaultUpdate(ecosystem, directory string) Update {
@@ -205,38 +442,151 @@ func newDefaultUpdate(ecosystem, directory string) Update {
 	}
 }
 
+// key identifies an update by ecosystem and directories, the
+// directories are cleaned so '/foo/' and 'foo' are equivalent
+func (u Update) key() string {
+	dirs := []string{}
+	for _, dir := range u.directories() {
+		dirs = append(dirs, path.Clean("/"+dir))
+	}
+	return u.PackageEcoSystem + strings.Join(dirs, ",")
+}
+
+// directories returns the directories the update applies to
+func (u Update) directories() []string {
+	if len(u.Directories) > 0 {
+		return u.Directories
+	}
+	return []string{u.Directory}
+}
+
+// single returns an update for each of the directories
+func (u Update) single() []Update {
+	all := []Update{}
+	for _, dir := range u.directories() {
+		c := u
+		c.Directory, c.Directories = dir, nil
+		all = append(all, c)
+	}
+	return all
+}
+
 func (u Updates) Add(update Update) {
-	key := fmt.Sprintf("%s%s", update.PackageEcoSystem, update.Directory)
-	u[key] = update
+	u[update.key()] = update
 }
 
+// RemoveIfExists removes the update and the updates for each of its
+// directories, a glob directory removes every update it matches
 func (u Updates) RemoveIfExists(update Update) {
-	key := fmt.Sprintf("%s%s", update.PackageEcoSystem, update.Directory)
-	_, found := u[key]
-	if found {
-		delete(u, key)
+	delete(u, update.key())
+	for _, s := range update.single() {
+		for _, k := range u.matching(s) {
+			delete(u, k)
+		}
+	}
+}
+
+// Contains returns true if any of the update's directories are held
+func (u Updates) Contains(update Update) bool {
+	for _, s := range update.single() {
+		if len(u.matching(s)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matching returns the keys of the updates for a single directory
+// update, which may be a glob
+func (u Updates) matching(single Update) []string {
+	if !isGlob(single.Directory) {
+		if _, found := u[single.key()]; found {
+			return []string{single.key()}
+		}
+		return nil
 	}
+
+	keys := []string{}
+	for k, v := range u {
+		if v.PackageEcoSystem == single.PackageEcoSystem && len(v.Directories) == 0 &&
+			matchDirectory(single.Directory, v.Directory) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
 }
 
+// ToArray returns the updates ordered by ecosystem then directory
 func (u Updates) ToArray() []Update {
 	all := []Update{}
 	for _, v := range u {
 		all = append(all, v)
 	}
+	sort.Slice(all, func(i, j int) bool {
+		return lessUpdate(all[i].PackageEcoSystem, all[i].directories()[0], all[j].PackageEcoSystem, all[j].directories()[0])
+	})
 	return all
 }
 
+// Collapse returns the updates with those of the same ecosystem and
+// settings merged into a single update using directories
+func (u Updates) Collapse() (Updates, error) {
+	type group struct {
+		first Update
+		dirs  []string
+	}
+	groups := map[string]*group{}
+	order := []string{}
+
+	for _, update := range u.ToArray() {
+		settings := update
+		settings.Directory, settings.Directories = "", nil
+		if e, _ := LookupEcosystem(update.PackageEcoSystem); !e.Directories {
+			// keep the directory so the update is never merged
+			settings.Directory = update.key()
+		}
+		b, err := yaml.Marshal(settings)
+		if err != nil {
+			return nil, err
+		}
+		k := string(b)
+		g, found := groups[k]
+		if !found {
+			g = &group{first: update}
+			groups[k] = g
+			order = append(order, k)
+		}
+		g.dirs = append(g.dirs, update.directories()...)
+	}
+
+	collapsed := Updates{}
+	for _, k := range order {
+		g := groups[k]
+		update := g.first
+		if len(g.dirs) > 1 {
+			sort.Strings(g.dirs)
+			update.Directory, update.Directories = "", g.dirs
+		}
+		collapsed.Add(update)
+	}
+	return collapsed, nil
+}
+
 func (u Updates) Empty() bool {
 	return len(u) == 0
 }
 
+// ApplyAllTo appends the updates to those in the document, adding the
+// version and updates keys if they are missing (e.g. an empty file)
 func (u Updates) ApplyAllTo(n *yaml.Node) error {
 
-	all := u.ToArray()
+	if u.Empty() {
+		return nil
+	}
 
 	// we need to convert the existing updates to
 	// yaml and then parse again into Node(s)
-	d, err := yaml.Marshal(all)
+	d, err := yaml.Marshal(u.ToArray())
 	if err != nil {
 		return err
 	}
@@ -245,20 +595,36 @@ func (u Updates) ApplyAllTo(n *yaml.Node) error {
 		return err
 	}
 
+	if len(n.Content) == 0 {
+		n.Kind = yaml.DocumentNode
+		n.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if root := n.Content[0]; root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		n.Content[0] = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
 	root := n.Content[0]
-	var previous *yaml.Node
-	for i, child := range root.Content {
-		if previous != nil && previous.Value == "updates" {
-			// should have found the sequence (array) of existing updates
-			if root.Content[i].Tag == "!!null" {
-				root.Content[i] = yy.Content[0]
-			} else {
-				root.Content[i].Content = append(root.Content[i].Content, yy.Content[0].Content...)
-			}
-			return nil
-		}
-		previous = child
+	if root.Kind != yaml.MappingNode {
+		return errors.New("dependabot configuration is not a mapping")
 	}
 
+	if lookup(root, "version") == nil {
+		root.Content = append([]*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"},
+			{Kind: yaml.ScalarNode, Tag: "!!int", Value: "2"},
+		}, root.Content...)
+	}
+
+	if seq := findUpdates(n); seq != nil {
+		if len(seq.Content) == 0 {
+			// updates: [] would otherwise stay on one line
+			seq.Style = 0
+		}
+		seq.Content = append(seq.Content, yy.Content[0].Content...)
+		return nil
+	}
+	if v := lookup(root, "updates"); v != nil && v.Tag != "!!null" {
+		return errors.New("dependabot updates is not a list")
+	}
+	set(root, "updates", yy.Content[0])
 	return nil
 }
@@ -0,0 +1,86 @@
+package dependabot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WellKnown_Match(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path      string
+		ecosystem string
+		directory string
+		found     bool
+	}{
+		{path: "composer.json", ecosystem: "composer", directory: "/", found: true},
+		{path: "svc/api/composer.lock", ecosystem: "composer", directory: "/svc/api", found: true},
+		{path: "src/App/App.csproj", ecosystem: "nuget", directory: "/src/App", found: true},
+		{path: "Lib.fsproj", ecosystem: "nuget", directory: "/", found: true},
+		{path: "foo.gemspec", ecosystem: "bundler", directory: "/", found: true},
+		{path: "deploy/Dockerfile.prod", ecosystem: "docker", directory: "/deploy", found: true},
+		{path: "deploy/api.dockerfile", ecosystem: "docker", directory: "/deploy", found: true},
+		{path: "Containerfile", ecosystem: "docker", directory: "/", found: true},
+		{path: "api/Pipfile", ecosystem: "pip", directory: "/api", found: true},
+		{path: "api/poetry.lock", ecosystem: "pip", directory: "/api", found: true},
+		{path: "setup.cfg", found: false},
+		{path: "requirements-dev.txt", ecosystem: "pip", directory: "/", found: true},
+		{path: "api/requirements/base.txt", ecosystem: "pip", directory: "/api", found: true},
+		{path: "api/uv.lock", ecosystem: "uv", directory: "/api", found: true},
+		{path: "mix.exs", ecosystem: "mix", directory: "/", found: true},
+		{path: "README.md", found: false},
+		{path: "docs/composer.json.txt", found: false},
+	}
+
+	for _, tt := range tests {
+		ecosystem, dir, found := wellKnown.match(tt.path)
+		require.Equal(t, tt.found, found, tt.path)
+		require.Equal(t, tt.ecosystem, ecosystem, tt.path)
+		require.Equal(t, tt.directory, dir, tt.path)
+	}
+}
+
+func Test_Rule_Match_Path_Pattern(t *testing.T) {
+	t.Parallel()
+
+	r := rule{pattern: "requirements/*.txt", ecosystem: "pip"}
+
+	dir, ok := r.match("services/api/requirements/dev.txt")
+	require.True(t, ok)
+	require.Equal(t, "/services/api", dir)
+
+	dir, ok = r.match("requirements/dev.txt")
+	require.True(t, ok)
+	require.Equal(t, "/", dir)
+
+	_, ok = r.match("dev.txt")
+	require.False(t, ok)
+}
+
+func Test_Updates_Collapse_Keeps_Different_Settings(t *testing.T) {
+	t.Parallel()
+
+	daily := newDefaultUpdate("gomod", "/c")
+	daily.Schedule.Interval = "daily"
+
+	updates := Updates{}
+	updates.Add(newDefaultUpdate("gomod", "/b"))
+	updates.Add(newDefaultUpdate("gomod", "/a"))
+	updates.Add(daily)
+	updates.Add(newDefaultUpdate("npm", "/"))
+
+	collapsed, err := updates.Collapse()
+	require.Nil(t, err)
+
+	all := collapsed.ToArray()
+	require.Len(t, all, 3)
+	require.Equal(t, []string{"/a", "/b"}, all[0].Directories)
+	require.Empty(t, all[0].Directory)
+	require.Equal(t, "/c", all[1].Directory)
+	require.Equal(t, "/", all[2].Directory)
+
+	collapsed.RemoveIfExists(all[0])
+	require.Len(t, collapsed, 2)
+}
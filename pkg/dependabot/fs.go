@@ -0,0 +1,72 @@
+package dependabot
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type (
+	// WriteFS is a file system that can be written to as well as read
+	WriteFS interface {
+		fs.FS
+		// WriteFile writes data to the named file, creating it if necessary
+		WriteFile(name string, data []byte, perm fs.FileMode) error
+		// MkdirAll creates the named directory along with any parents
+		MkdirAll(name string, perm fs.FileMode) error
+	}
+
+	// dirFS is a WriteFS for a folder on disk
+	dirFS struct {
+		fs.FS
+		dir string
+	}
+)
+
+// DirFS returns a WriteFS for the folder on disk
+func DirFS(dir string) WriteFS {
+	return dirFS{
+		FS:  os.DirFS(dir),
+		dir: dir,
+	}
+}
+
+func (d dirFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	p, err := d.join("write", name)
+	if err != nil {
+		return err
+	}
+	return writeFile(p, data, perm)
+}
+
+func (d dirFS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := d.join("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, perm)
+}
+
+// join returns the path on disk for a slash separated name
+func (d dirFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(d.dir, filepath.FromSlash(name)), nil
+}
+
+// writeFile writes data to the named file on disk ensuring the file has
+// the permissions perm, even if the file already exists
+func writeFile(name string, data []byte, perm fs.FileMode) error {
+	if err := os.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	if fi.Mode().Perm() != perm.Perm() {
+		return os.Chmod(name, perm.Perm())
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package dependabot
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// gitFS is a read only fs.FS over the tree of a git commit,
+	// files are read from the object database on demand
+	gitFS struct {
+		// dir is the folder on disk holding the git repository
+		dir     string
+		entries map[string]*gitEntry
+		// children holds the sorted entries of each folder
+		children map[string][]fs.DirEntry
+	}
+
+	// gitEntry is a file or folder in the tree
+	gitEntry struct {
+		name   string
+		mode   fs.FileMode
+		size   int64
+		object string
+	}
+
+	gitFile struct {
+		name   string
+		entry  *gitEntry
+		fsys   *gitFS
+		reader *bytes.Reader
+	}
+
+	gitDir struct {
+		entry    *gitEntry
+		children []fs.DirEntry
+		offset   int
+	}
+)
+
+// GitFS returns a read only file system holding the files of the
+// commit ref resolves to, in the git repository at dir
+func GitFS(dir, ref string) (fs.FS, error) {
+
+	commit, err := getCommandOutput(dir, "git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return nil, errors.Errorf("unknown git ref %s", ref)
+	}
+
+	e := exec.Command("git", "ls-tree", "-r", "-l", "-z", "--full-tree", commit)
+	e.Dir = dir
+	data, err := e.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing files for git ref %s", ref)
+	}
+
+	g := &gitFS{
+		dir:      dir,
+		entries:  map[string]*gitEntry{".": {name: ".", mode: fs.ModeDir | 0755}},
+		children: map[string][]fs.DirEntry{},
+	}
+
+	for _, record := range strings.Split(string(data), "\x00") {
+		if record == "" {
+			continue
+		}
+		if err := g.add(record); err != nil {
+			return nil, errors.Wrapf(err, "error listing files for git ref %s", ref)
+		}
+	}
+
+	for _, c := range g.children {
+		sort.Slice(c, func(i, j int) bool { return c[i].Name() < c[j].Name() })
+	}
+	return g, nil
+}
+
+// add parses a record from git ls-tree -l e.g.
+// '100644 blob 3b18e512dba79e4c8300dd08aeb37f8e728b8dad      12\tpath/to/file'
+func (g *gitFS) add(record string) error {
+	meta, name, found := strings.Cut(record, "\t")
+	fields := strings.Fields(meta)
+	if !found || len(fields) != 4 { //nolint:gomnd
+		return errors.Errorf("unexpected tree entry %q", record)
+	}
+
+	// submodules are commits in another repository
+	if fields[1] != "blob" {
+		return nil
+	}
+
+	// the permissions git would check the file out with
+	entry := &gitEntry{name: path.Base(name), object: fields[2], mode: 0644}
+	switch fields[0] {
+	case "100755":
+		entry.mode = 0755
+	case "120000":
+		entry.mode = fs.ModeSymlink | 0777
+	}
+	size, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return errors.Errorf("unexpected tree entry %q", record)
+	}
+	entry.size = size
+
+	g.insert(name, entry)
+	return nil
+}
+
+// insert adds the entry creating any missing parent folders
+func (g *gitFS) insert(name string, entry *gitEntry) {
+	g.entries[name] = entry
+	dir := path.Dir(name)
+	g.children[dir] = append(g.children[dir], entry)
+
+	if _, found := g.entries[dir]; !found {
+		g.insert(dir, &gitEntry{name: path.Base(dir), mode: fs.ModeDir | 0755})
+	}
+}
+
+// Open opens the named file or folder
+func (g *gitFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, found := g.entries[name]
+	if !found {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.IsDir() {
+		return &gitDir{entry: entry, children: g.children[name]}, nil
+	}
+	return &gitFile{name: name, entry: entry, fsys: g}, nil
+}
+
+// ReadFile reads the content of the named file from git
+func (g *gitFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, found := g.entries[name]
+	if !found {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+
+	e := exec.Command("git", "cat-file", "blob", entry.object)
+	e.Dir = g.dir
+	data, err := e.Output()
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+func (e *gitEntry) Name() string               { return e.name }
+func (e *gitEntry) Size() int64                { return e.size }
+func (e *gitEntry) Mode() fs.FileMode          { return e.mode }
+func (e *gitEntry) ModTime() time.Time         { return time.Time{} }
+func (e *gitEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e *gitEntry) Sys() interface{}           { return nil }
+func (e *gitEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e *gitEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+func (f *gitFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *gitFile) Close() error               { return nil }
+
+func (f *gitFile) Read(b []byte) (int, error) {
+	if f.reader == nil {
+		data, err := f.fsys.ReadFile(f.name)
+		if err != nil {
+			return 0, err
+		}
+		f.reader = bytes.NewReader(data)
+	}
+	return f.reader.Read(b)
+}
+
+func (d *gitDir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+func (d *gitDir) Close() error               { return nil }
+
+func (d *gitDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: errors.New("is a directory")}
+}
+
+func (d *gitDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := d.children[d.offset:]
+	if count <= 0 {
+		d.offset = len(d.children)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(remaining) {
+		count = len(remaining)
+	}
+	d.offset += count
+	return remaining[:count], nil
+}
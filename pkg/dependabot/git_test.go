@@ -0,0 +1,144 @@
+package dependabot
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newGitRepo creates a git repository holding files in a single commit
+func newGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.Nil(t, os.MkdirAll(filepath.Dir(p), 0700))
+		require.Nil(t, os.WriteFile(p, []byte(content), 0600))
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=dependr", "-c", "user.email=dependr@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.Nil(t, err, string(out))
+	}
+	return dir
+}
+
+func Test_GitFS(t *testing.T) {
+	t.Parallel()
+
+	dir := newGitRepo(t, map[string]string{
+		"go.mod":                 "module foo\n",
+		"web/package.json":       "{}\n",
+		".github/dependabot.yml": "version: 2\nupdates: []\n",
+	})
+
+	// changes to the working tree are not visible
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte{}, 0600))
+
+	fsys, err := GitFS(dir, "HEAD")
+	require.Nil(t, err)
+
+	require.Nil(t, fstest.TestFS(fsys, "go.mod", "web/package.json", ".github/dependabot.yml"))
+
+	data, err := fs.ReadFile(fsys, "web/package.json")
+	require.Nil(t, err)
+	require.Equal(t, "{}\n", string(data))
+
+	require.False(t, pathExists(fsys, "Cargo.toml"))
+
+	n, err := LoadFS(fsys, ".")
+	require.Nil(t, err)
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+	require.Len(t, plan.Added, 2)
+	require.ErrorIs(t, n.Write(plan), ErrReadOnly)
+}
+
+func Test_GitFS_Unknown_Ref(t *testing.T) {
+	t.Parallel()
+
+	dir := newGitRepo(t, map[string]string{"go.mod": ""})
+
+	_, err := GitFS(dir, "does-not-exist")
+	require.NotNil(t, err)
+}
+
+func Test_Plan_In_Worktree(t *testing.T) {
+	t.Parallel()
+
+	dir := newGitRepo(t, map[string]string{
+		"go.mod":       "module foo\n",
+		"build/go.mod": "module build\n",
+	})
+	require.Nil(t, os.WriteFile(filepath.Join(dir, ".git", "info", "exclude"), []byte("build/\n"), 0600))
+
+	wt := filepath.Join(t.TempDir(), "wt")
+	out, err := exec.Command("git", "-C", dir, "worktree", "add", "-q", wt).CombinedOutput()
+	require.Nil(t, err, string(out))
+
+	n, err := LoadOrCreate(wt)
+	require.Nil(t, err)
+
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+	require.Len(t, plan.Added, 1)
+	require.Equal(t, "/", plan.Added[0].Directory)
+}
+
+func Test_Plan_Reads_Repository_Excludes_File(t *testing.T) {
+	t.Parallel()
+
+	dir := newGitRepo(t, map[string]string{
+		"go.mod":       "module foo\n",
+		"build/go.mod": "module build\n",
+	})
+	excludes := filepath.Join(t.TempDir(), "ignore")
+	require.Nil(t, os.WriteFile(excludes, []byte("build/\n"), 0600))
+	out, err := exec.Command("git", "-C", dir, "config", "core.excludesFile", excludes).CombinedOutput()
+	require.Nil(t, err, string(out))
+
+	n, err := LoadOrCreate(dir)
+	require.Nil(t, err)
+
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+	require.Len(t, plan.Added, 1)
+	require.Equal(t, "/", plan.Added[0].Directory)
+}
+
+func Test_Plan_From_Ref_Uses_Default_File_Mode(t *testing.T) {
+	t.Parallel()
+
+	dir := newGitRepo(t, map[string]string{
+		"go.mod":                 "module foo\n",
+		"web/package.json":       "{}\n",
+		".github/dependabot.yml": "version: 2\nupdates: []\n",
+	})
+
+	fsys, err := GitFS(dir, "HEAD")
+	require.Nil(t, err)
+	fi, err := fs.Stat(fsys, ".github/dependabot.yml")
+	require.Nil(t, err)
+	require.Equal(t, fs.FileMode(0644), fi.Mode().Perm())
+
+	n, err := Load(dir, WithRef("HEAD"))
+	require.Nil(t, err)
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+
+	output := filepath.Join(t.TempDir(), "out.yml")
+	require.Nil(t, plan.WriteFile(output))
+	fi, err = os.Stat(output)
+	require.Nil(t, err)
+	require.Equal(t, defaultFileMode, fi.Mode().Perm())
+}
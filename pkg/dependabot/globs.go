@@ -0,0 +1,110 @@
+package dependabot
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mdevilliers/depender/pkg/ignore"
+)
+
+// Globs returns the updates with the directories of each update
+// replaced by a glob (e.g. /services/*) where the glob matches
+// exactly the same directories in the repository.
+func (u Updates) Globs(fsys fs.FS) (Updates, error) {
+	globbed := Updates{}
+	for _, update := range u {
+		if len(update.Directories) > 1 {
+			dirs, err := globDirectories(fsys, update.Directories)
+			if err != nil {
+				return nil, err
+			}
+			update.Directories = dirs
+		}
+		globbed.Add(update)
+	}
+	return globbed, nil
+}
+
+// globDirectories replaces the directories sharing a parent with a
+// single glob when every directory in the parent is included
+func globDirectories(fsys fs.FS, dirs []string) ([]string, error) {
+	children := map[string][]string{}
+	for _, dir := range dirs {
+		clean := path.Clean("/" + dir)
+		if clean == "/" {
+			continue
+		}
+		parent := path.Dir(clean)
+		children[parent] = append(children[parent], clean)
+	}
+
+	globs := map[string]string{}
+	for parent, kids := range children {
+		if len(kids) < 2 {
+			continue
+		}
+		pattern := path.Join(parent, "*")
+		found, err := globDirs(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(kids)
+		if strings.Join(found, "\x00") != strings.Join(kids, "\x00") {
+			continue
+		}
+		for _, kid := range kids {
+			globs[kid] = pattern
+		}
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, dir := range dirs {
+		if glob, ok := globs[path.Clean("/"+dir)]; ok {
+			dir = glob
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			result = append(result, dir)
+		}
+	}
+	return result, nil
+}
+
+// globDirs returns the sorted directories in the repository matching pattern
+func globDirs(fsys fs.FS, pattern string) ([]string, error) {
+	matches, err := fs.Glob(fsys, strings.TrimPrefix(pattern, "/"))
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{}
+	for _, m := range matches {
+		if fi, err := fs.Stat(fsys, m); err == nil && fi.IsDir() {
+			dirs = append(dirs, "/"+m)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// isGlob returns true if the directory is a glob pattern
+func isGlob(dir string) bool {
+	return strings.ContainsAny(dir, "*?[")
+}
+
+// matchDirectory returns true if the directory matches a dependabot
+// directory glob, where '**' matches any number of directories
+func matchDirectory(pattern, dir string) bool {
+	return ignore.MatchPath(path.Clean("/"+pattern), path.Clean("/"+dir))
+}
+
+// segments splits a directory in to its names, the root has none
+func segments(dir string) []string {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	if dir == "" {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
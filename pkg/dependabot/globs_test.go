@@ -0,0 +1,82 @@
+package dependabot
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MatchDirectory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		dir     string
+		match   bool
+	}{
+		{"/services/*", "/services/a", true},
+		{"/services/*", "services/a/", true},
+		{"/services/*", "/services", false},
+		{"/services/*", "/services/a/b", false},
+		{"/services/**", "/services/a/b", true},
+		{"/services/**", "/services", true},
+		{"/**/web", "/apps/x/web", true},
+		{"/**", "/", true},
+		{"/lib*", "/library", true},
+		{"/lib*", "/src/lib", false},
+	}
+
+	for _, tc := range tests {
+		require.Equal(t, tc.match, matchDirectory(tc.pattern, tc.dir), "%s %s", tc.pattern, tc.dir)
+	}
+}
+
+func Test_GlobDirectories(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"services/a/go.mod":   {},
+		"services/b/go.mod":   {},
+		"tools/x/go.mod":      {},
+		"tools/y/go.mod":      {},
+		"tools/docs/index.md": {},
+	}
+
+	dirs, err := globDirectories(fsys, []string{"/", "/services/a", "/services/b", "/tools/x", "/tools/y"})
+
+	require.Nil(t, err)
+	// tools/docs would also be matched by /tools/*
+	require.Equal(t, []string{"/", "/services/*", "/tools/x", "/tools/y"}, dirs)
+}
+
+func Test_Plan_Globs_Are_Not_Added_Again(t *testing.T) {
+	t.Parallel()
+
+	fsys := memFS{fstest.MapFS{
+		"services/a/go.mod": {},
+		"services/b/go.mod": {},
+		"services/c/go.mod": {},
+		"web/package.json":  {},
+	}}
+	opts := ScanOptions{Globs: true, Prune: true}
+
+	n, err := LoadOrCreateFS(fsys, ".")
+	require.Nil(t, err)
+	require.Nil(t, n.Scan(opts))
+
+	generated := string(fsys.MapFS[".github/dependabot.yml"].Data)
+	require.Contains(t, generated, `  - package-ecosystem: gomod
+    directories:
+      - /services/*
+`)
+
+	n, err = LoadFS(fsys, ".")
+	require.Nil(t, err)
+	plan, err := n.Plan(opts)
+	require.Nil(t, err)
+	require.Empty(t, plan.Added)
+	require.Empty(t, plan.Pruned)
+	require.Len(t, plan.Present, 4)
+	require.Equal(t, generated, string(plan.Generated))
+}
@@ -0,0 +1,62 @@
+package dependabot
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// detectGo matches Go modules the go command would build. Modules in
+// directories the go command ignores (testdata, vendor or those
+// starting with '_' or '.') are skipped, as are modules inside a Go
+// workspace that the go.work does not use. A vendored module sets vendor.
+func detectGo(p string, fsys fs.FS) ([]Match, error) {
+	moduleDir, ok := inspected(p, "gomod")
+	if !ok {
+		return nil, nil
+	}
+
+	dir := cleanPath(moduleDir)
+	for _, name := range segments(dir) {
+		if name == "testdata" || name == "vendor" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+			return nil, nil
+		}
+	}
+
+	if work, uses := goWorkspace(fsys, dir); work != "" && !uses[dir] {
+		return nil, nil
+	}
+
+	return []Match{{
+		Ecosystem: "gomod",
+		Directory: moduleDir,
+		Manifest:  p,
+		Vendor:    pathExists(fsys, path.Join(dir, "vendor", "modules.txt")),
+	}}, nil
+}
+
+// goWorkspace returns the directory of the nearest go.work at or above
+// dir and the module directories it uses, or an empty string if dir is
+// not in a workspace
+func goWorkspace(fsys fs.FS, dir string) (string, map[string]bool) {
+	for current := dir; ; current = path.Dir(current) {
+		name := path.Join(current, "go.work")
+		if data, err := fs.ReadFile(fsys, name); err == nil {
+			work, err := modfile.ParseWork(name, data, nil)
+			if err != nil {
+				// the go command would refuse to use it
+				return "", nil
+			}
+			uses := map[string]bool{}
+			for _, use := range work.Use {
+				uses[path.Join(current, use.Path)] = true
+			}
+			return current, uses
+		}
+		if current == "." {
+			return "", nil
+		}
+	}
+}
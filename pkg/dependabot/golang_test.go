@@ -0,0 +1,106 @@
+package dependabot
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DetectGo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("vendored module", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"go.mod":                      {},
+			"vendor/modules.txt":          {},
+			"vendor/example.com/x/go.mod": {},
+		}
+
+		matches, err := detectGo("go.mod", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "gomod", Directory: "/", Manifest: "go.mod", Vendor: true}}, matches)
+
+		matches, err = detectGo("vendor/example.com/x/go.mod", fsys)
+		require.Nil(t, err)
+		require.Nil(t, matches)
+	})
+
+	t.Run("directories the go command ignores", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"pkg/testdata/mod/go.mod": {},
+			"_examples/demo/go.mod":   {},
+			".tools/go.mod":           {},
+		}
+
+		for _, p := range []string{"pkg/testdata/mod/go.mod", "_examples/demo/go.mod", ".tools/go.mod"} {
+			matches, err := detectGo(p, fsys)
+			require.Nil(t, err)
+			require.Nil(t, matches, p)
+		}
+	})
+
+	t.Run("modules used by a workspace", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"go.work":                {Data: []byte("go 1.21\n\nuse (\n\t.\n\t./api\n)\n")},
+			"go.mod":                 {},
+			"api/go.mod":             {},
+			"api/go.sum":             {},
+			"examples/client/go.mod": {},
+		}
+
+		matches, err := detectGo("go.mod", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "gomod", Directory: "/", Manifest: "go.mod"}}, matches)
+
+		matches, err = detectGo("api/go.sum", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "gomod", Directory: "/api", Manifest: "api/go.sum"}}, matches)
+
+		// not used by the go.work
+		matches, err = detectGo("examples/client/go.mod", fsys)
+		require.Nil(t, err)
+		require.Nil(t, matches)
+
+		matches, err = detectGo("go.work", fsys)
+		require.Nil(t, err)
+		require.Nil(t, matches)
+	})
+
+	t.Run("unparsable go.work is ignored", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"go.work":    {Data: []byte("use (\n")},
+			"svc/go.mod": {},
+		}
+
+		matches, err := detectGo("svc/go.mod", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "gomod", Directory: "/svc", Manifest: "svc/go.mod"}}, matches)
+	})
+}
+
+func Test_Plan_Vendored_Go_Module(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"go.mod":             {},
+		"vendor/modules.txt": {},
+	}
+
+	n, err := LoadOrCreateFS(fsys, ".")
+	require.Nil(t, err)
+	plan, err := n.Plan(ScanOptions{})
+	require.Nil(t, err)
+
+	require.Len(t, plan.Added, 1)
+	require.True(t, plan.Added[0].Vendor)
+	require.Contains(t, string(plan.Generated), "vendor: true")
+}
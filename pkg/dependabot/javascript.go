@@ -0,0 +1,125 @@
+package dependabot
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	npmLockFiles = []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml"}
+	bunLockFiles = []string{"bun.lock", "bun.lockb"}
+)
+
+// detectJavaScript matches npm and bun projects. Workspace packages
+// share the lockfile next to the root package.json, so are reported
+// against that directory. Bun is only used when there is a bun lockfile
+// and no npm, yarn or pnpm one.
+func detectJavaScript(p string, fsys fs.FS) ([]Match, error) {
+	dir, ok := inspected(p, "npm", "bun")
+	if !ok {
+		return nil, nil
+	}
+
+	root, err := workspaceRoot(fsys, cleanPath(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	ecosystem := "npm"
+	if anyExists(fsys, root, bunLockFiles) && !anyExists(fsys, root, npmLockFiles) {
+		ecosystem = "bun"
+	}
+	return []Match{{Ecosystem: ecosystem, Directory: path.Clean("/" + root), Manifest: p}}, nil
+}
+
+// workspaceRoot returns the nearest parent of dir declaring dir as a
+// workspace member, or dir if it is not a member of a workspace
+func workspaceRoot(fsys fs.FS, dir string) (string, error) {
+	for parent := dir; parent != "."; {
+		parent = path.Dir(parent)
+
+		patterns, err := workspacePatterns(fsys, parent)
+		if err != nil {
+			return "", err
+		}
+		rel := dir
+		if parent != "." {
+			rel = strings.TrimPrefix(dir, parent+"/")
+		}
+		if workspaceMember(patterns, rel) {
+			return parent, nil
+		}
+	}
+	return dir, nil
+}
+
+// workspacePatterns returns the workspace packages declared by the
+// package.json and pnpm-workspace.yaml in dir
+func workspacePatterns(fsys fs.FS, dir string) ([]string, error) {
+	patterns := []string{}
+
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	ok, err := readManifest(fsys, path.Join(dir, "package.json"), func(data []byte) error {
+		return json.Unmarshal(data, &pkg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ok && len(pkg.Workspaces) > 0 {
+		var list []string
+		// yarn also allows {"packages": [...]}
+		var object struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal(pkg.Workspaces, &list) == nil {
+			patterns = append(patterns, list...)
+		} else if json.Unmarshal(pkg.Workspaces, &object) == nil {
+			patterns = append(patterns, object.Packages...)
+		}
+	}
+
+	var workspace struct {
+		Packages []string `yaml:"packages"`
+	}
+	ok, err = readManifest(fsys, path.Join(dir, "pnpm-workspace.yaml"), func(data []byte) error {
+		return yaml.Unmarshal(data, &workspace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		patterns = append(patterns, workspace.Packages...)
+	}
+	return patterns, nil
+}
+
+// workspaceMember returns true if the relative directory matches the
+// workspace patterns, a pattern starting with '!' excludes directories
+// and the last matching pattern wins
+func workspaceMember(patterns []string, rel string) bool {
+	member := false
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./")
+		if matchDirectory(pattern, rel) {
+			member = !exclude
+		}
+	}
+	return member
+}
+
+// anyExists returns true if any of the files exist in dir
+func anyExists(fsys fs.FS, dir string, files []string) bool {
+	for _, f := range files {
+		if pathExists(fsys, path.Join(dir, f)) {
+			return true
+		}
+	}
+	return false
+}
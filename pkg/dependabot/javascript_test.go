@@ -0,0 +1,108 @@
+package dependabot
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DetectJavaScript(t *testing.T) {
+	t.Parallel()
+
+	t.Run("npm workspace members belong to the root", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"package.json":                  {Data: []byte(`{"workspaces": ["packages/*", "!packages/private"]}`)},
+			"package-lock.json":             {},
+			"packages/a/package.json":       {Data: []byte(`{}`)},
+			"packages/private/package.json": {Data: []byte(`{}`)},
+			"examples/demo/package.json":    {Data: []byte(`{}`)},
+		}
+
+		matches, err := detectJavaScript("packages/a/package.json", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "npm", Directory: "/", Manifest: "packages/a/package.json"}}, matches)
+
+		matches, err = detectJavaScript("package-lock.json", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "npm", Directory: "/", Manifest: "package-lock.json"}}, matches)
+
+		// excluded by '!' or not listed at all
+		for _, p := range []string{"packages/private/package.json", "examples/demo/package.json"} {
+			matches, err = detectJavaScript(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "npm", Directory: directoryOf(p), Manifest: p}}, matches, p)
+		}
+	})
+
+	t.Run("pnpm workspace", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"web/pnpm-workspace.yaml":   {Data: []byte("packages:\n  - 'apps/**'\n")},
+			"web/pnpm-lock.yaml":        {},
+			"web/apps/x/y/package.json": {Data: []byte(`{}`)},
+		}
+
+		for _, p := range []string{"web/pnpm-workspace.yaml", "web/apps/x/y/package.json"} {
+			matches, err := detectJavaScript(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "npm", Directory: "/web", Manifest: p}}, matches, p)
+		}
+	})
+
+	t.Run("yarn berry packages object", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"package.json":     {Data: []byte(`{"workspaces": {"packages": ["cli"]}}`)},
+			".yarnrc.yml":      {},
+			"cli/package.json": {Data: []byte(`{}`)},
+		}
+
+		for _, p := range []string{".yarnrc.yml", "cli/package.json"} {
+			matches, err := detectJavaScript(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "npm", Directory: "/", Manifest: p}}, matches, p)
+		}
+	})
+
+	t.Run("bun lockfile", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"package.json":        {Data: []byte(`{"workspaces": ["pkgs/*"]}`)},
+			"bun.lock":            {},
+			"pkgs/a/package.json": {Data: []byte(`{}`)},
+		}
+
+		for _, p := range []string{"bun.lock", "pkgs/a/package.json"} {
+			matches, err := detectJavaScript(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "bun", Directory: "/", Manifest: p}}, matches, p)
+		}
+	})
+
+	t.Run("unparsable package.json is not a workspace", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"package.json":        {Data: []byte(`{"workspaces": [`)},
+			"pkgs/a/package.json": {Data: []byte(`{}`)},
+		}
+
+		matches, err := detectJavaScript("pkgs/a/package.json", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "npm", Directory: "/pkgs/a", Manifest: "pkgs/a/package.json"}}, matches)
+	})
+
+	t.Run("other files", func(t *testing.T) {
+		t.Parallel()
+
+		matches, err := detectJavaScript("README.md", fstest.MapFS{"README.md": {}})
+		require.Nil(t, err)
+		require.Empty(t, matches)
+	})
+}
@@ -0,0 +1,108 @@
+package dependabot
+
+import (
+	"encoding/xml"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+var (
+	gradleSettingsFiles = []string{"settings.gradle", "settings.gradle.kts"}
+)
+
+// detectGradle matches gradle builds. A settings file marks the root of
+// a multi-project build and each subproject is reported against it,
+// builds without one stand alone.
+func detectGradle(p string, fsys fs.FS) ([]Match, error) {
+	dir, ok := inspected(p, "gradle")
+	if !ok {
+		return nil, nil
+	}
+
+	root := gradleSettingsRoot(fsys, cleanPath(dir))
+	return []Match{{Ecosystem: "gradle", Directory: path.Clean("/" + root), Manifest: p}}, nil
+}
+
+// gradleSettingsRoot returns the nearest directory at or above dir
+// holding a settings file, or dir if there is none
+func gradleSettingsRoot(fsys fs.FS, dir string) string {
+	for current := dir; ; current = path.Dir(current) {
+		if anyExists(fsys, current, gradleSettingsFiles) {
+			return current
+		}
+		if current == "." {
+			return dir
+		}
+	}
+}
+
+// pom holds the modules of a maven aggregator
+type pom struct {
+	Modules  []string `xml:"modules>module"`
+	Profiles []struct {
+		Modules []string `xml:"modules>module"`
+	} `xml:"profiles>profile"`
+}
+
+// detectMaven matches maven projects. Modules are reported against the
+// outermost pom that lists them, directly or through a nested
+// aggregator, so a multi-module build has a single update.
+func detectMaven(p string, fsys fs.FS) ([]Match, error) {
+	dir, ok := inspected(p, "maven")
+	if !ok {
+		return nil, nil
+	}
+
+	root, err := mavenAggregator(fsys, cleanPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	return []Match{{Ecosystem: "maven", Directory: path.Clean("/" + root), Manifest: p}}, nil
+}
+
+// mavenAggregator returns the top most directory whose pom lists dir
+// as a module, directly or through other aggregators
+func mavenAggregator(fsys fs.FS, dir string) (string, error) {
+	root := dir
+	for parent := root; parent != "."; {
+		parent = path.Dir(parent)
+
+		modules, err := mavenModules(fsys, parent)
+		if err != nil {
+			return "", err
+		}
+		if modules[root] {
+			// keep looking for an aggregator of the aggregator
+			root = parent
+		}
+	}
+	return root, nil
+}
+
+// mavenModules returns the directories of the modules listed by the
+// pom.xml in dir
+func mavenModules(fsys fs.FS, dir string) (map[string]bool, error) {
+	var project pom
+	ok, err := readManifest(fsys, path.Join(dir, "pom.xml"), func(data []byte) error {
+		return xml.Unmarshal(data, &project)
+	})
+	if !ok {
+		return nil, err
+	}
+
+	all := project.Modules
+	for _, profile := range project.Profiles {
+		all = append(all, profile.Modules...)
+	}
+
+	modules := map[string]bool{}
+	for _, m := range all {
+		m = strings.TrimSpace(m)
+		if strings.HasSuffix(m, ".xml") {
+			m = path.Dir(m)
+		}
+		modules[path.Join(dir, m)] = true
+	}
+	return modules, nil
+}
@@ -0,0 +1,129 @@
+package dependabot
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DetectGradle(t *testing.T) {
+	t.Parallel()
+
+	t.Run("projects belong to the settings root", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"settings.gradle.kts":                      {},
+			"build.gradle.kts":                         {},
+			"gradle/libs.versions.toml":                {},
+			"gradle/wrapper/gradle-wrapper.properties": {},
+			"app/build.gradle.kts":                     {},
+			"libs/core/build.gradle":                   {},
+		}
+
+		for p := range fsys {
+			matches, err := detectGradle(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "gradle", Directory: "/", Manifest: p}}, matches, p)
+		}
+	})
+
+	t.Run("nearest settings file wins", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"settings.gradle":             {},
+			"standalone/settings.gradle":  {},
+			"standalone/sub/build.gradle": {},
+		}
+
+		matches, err := detectGradle("standalone/sub/build.gradle", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "gradle", Directory: "/standalone", Manifest: "standalone/sub/build.gradle"}}, matches)
+	})
+
+	t.Run("build without settings", func(t *testing.T) {
+		t.Parallel()
+
+		matches, err := detectGradle("tools/build.gradle", fstest.MapFS{"tools/build.gradle": {}})
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "gradle", Directory: "/tools", Manifest: "tools/build.gradle"}}, matches)
+	})
+
+	t.Run("other files", func(t *testing.T) {
+		t.Parallel()
+
+		matches, err := detectGradle("docs/gradle.md", fstest.MapFS{"docs/gradle.md": {}})
+		require.Nil(t, err)
+		require.Empty(t, matches)
+	})
+}
+
+func Test_DetectMaven(t *testing.T) {
+	t.Parallel()
+
+	t.Run("modules belong to the outermost aggregator", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"pom.xml": {Data: []byte(`<project>
+  <modules>
+    <module>services</module>
+  </modules>
+</project>`)},
+			"services/pom.xml": {Data: []byte(`<project>
+  <modules>
+    <module>api</module>
+  </modules>
+</project>`)},
+			"services/api/pom.xml":  {Data: []byte(`<project/>`)},
+			"examples/demo/pom.xml": {Data: []byte(`<project/>`)},
+		}
+
+		for _, p := range []string{"pom.xml", "services/pom.xml", "services/api/pom.xml"} {
+			matches, err := detectMaven(p, fsys)
+			require.Nil(t, err)
+			require.Equal(t, []Match{{Ecosystem: "maven", Directory: "/", Manifest: p}}, matches, p)
+		}
+
+		// not listed as a module
+		matches, err := detectMaven("examples/demo/pom.xml", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "maven", Directory: "/examples/demo", Manifest: "examples/demo/pom.xml"}}, matches)
+	})
+
+	t.Run("profile modules naming a pom", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"pom.xml": {Data: []byte(`<project>
+  <profiles>
+    <profile>
+      <modules>
+        <module>batch/pom.xml</module>
+      </modules>
+    </profile>
+  </profiles>
+</project>`)},
+			"batch/pom.xml": {Data: []byte(`<project/>`)},
+		}
+
+		matches, err := detectMaven("batch/pom.xml", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "maven", Directory: "/", Manifest: "batch/pom.xml"}}, matches)
+	})
+
+	t.Run("unparsable aggregator is ignored", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{
+			"pom.xml":     {Data: []byte(`<project><modules>`)},
+			"api/pom.xml": {Data: []byte(`<project/>`)},
+		}
+
+		matches, err := detectMaven("api/pom.xml", fsys)
+		require.Nil(t, err)
+		require.Equal(t, []Match{{Ecosystem: "maven", Directory: "/api", Manifest: "api/pom.xml"}}, matches)
+	})
+}
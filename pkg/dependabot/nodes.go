@@ -0,0 +1,308 @@
+package dependabot
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// findUpdates returns the sequence node holding the updates or nil
+// if the document has no updates
+func findUpdates(doc *yaml.Node) *yaml.Node {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "updates" {
+			if v := root.Content[i+1]; v.Kind == yaml.SequenceNode {
+				return v
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// lookup returns the value node for key in a mapping node or nil
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalar returns the value of key in a mapping node or an empty string
+func scalar(m *yaml.Node, key string) string {
+	if v := lookup(m, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+// sortUpdates stable sorts the updates by ecosystem then directory,
+// comments are attached to the nodes so travel with them. True is
+// returned if the order changed.
+func sortUpdates(doc *yaml.Node) bool {
+	seq := findUpdates(doc)
+	if seq == nil {
+		return false
+	}
+	before := append([]*yaml.Node{}, seq.Content...)
+	sort.SliceStable(seq.Content, func(i, j int) bool {
+		a, b := seq.Content[i], seq.Content[j]
+		return lessUpdate(scalar(a, "package-ecosystem"), firstDirectory(a),
+			scalar(b, "package-ecosystem"), firstDirectory(b))
+	})
+	for i := range before {
+		if before[i] != seq.Content[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// directoryValues returns the directory or directories of an update
+func directoryValues(item *yaml.Node) []string {
+	values := []string{}
+	for _, dir := range directoryNodes(item) {
+		values = append(values, dir.Value)
+	}
+	return values
+}
+
+// firstDirectory returns the directory or first of the directories of an update
+func firstDirectory(item *yaml.Node) string {
+	if dirs := directoryNodes(item); len(dirs) > 0 {
+		return dirs[0].Value
+	}
+	return ""
+}
+
+// lessUpdate orders updates by ecosystem then directory
+func lessUpdate(ecosystemA, directoryA, ecosystemB, directoryB string) bool {
+	if ecosystemA != ecosystemB {
+		return ecosystemA < ecosystemB
+	}
+	return directoryA < directoryB
+}
+
+// pruneUpdates removes any update of a detectable ecosystem not found in
+// detected, returning the updates removed. Updates for ecosystems that
+// are never detected (e.g. helm) are written by hand so are kept.
+// Comments on the remaining updates are untouched.
+func pruneUpdates(doc *yaml.Node, detected Updates, detectable func(ecosystem string) bool) []Update {
+	seq := findUpdates(doc)
+	if seq == nil {
+		return nil
+	}
+
+	removed := []Update{}
+	kept := []*yaml.Node{}
+
+	for _, item := range seq.Content {
+		var u Update
+		if err := item.Decode(&u); err != nil || !detectable(u.PackageEcoSystem) || detected.Contains(u) {
+			// leave anything we do not understand alone
+			kept = append(kept, item)
+			continue
+		}
+		removed = append(removed, u)
+	}
+
+	seq.Content = kept
+	return removed
+}
+
+// reconcileUpdates edits the existing updates in place so the schedule,
+// labels, open-pull-requests-limit and commit-message prefix match those
+// in the policy for the ecosystem. Other keys and comments are untouched.
+func reconcileUpdates(doc *yaml.Node, policy func(ecosystem string) Update) []Change {
+	seq := findUpdates(doc)
+	if seq == nil {
+		return nil
+	}
+
+	changes := []Change{}
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		ecosystem := scalar(item, "package-ecosystem")
+		desired := policy(ecosystem)
+
+		record := func(field, from, to string) {
+			changes = append(changes, Change{
+				Ecosystem: ecosystem,
+				Directory: strings.Join(directoryValues(item), ","),
+				Field:     field,
+				From:      from,
+				To:        to,
+			})
+		}
+
+		s := desired.Schedule
+		for _, field := range [][2]string{
+			{"interval", s.Interval}, {"day", s.Day}, {"time", s.Time}, {"timezone", s.Timezone}, {"cronjob", s.Cronjob},
+		} {
+			if field[1] == "" {
+				continue
+			}
+			if from, changed := setScalar(mapping(item, "schedule"), field[0], field[1], "!!str"); changed {
+				record("schedule."+field[0], from, field[1])
+			}
+		}
+
+		// remove the values the interval no longer allows
+		if schedule := lookup(item, "schedule"); schedule != nil && s.Interval != "" {
+			if s.Interval != "weekly" {
+				if from, removed := unset(schedule, "day"); removed {
+					record("schedule.day", from, "")
+				}
+			}
+			if s.Interval != "cron" {
+				if from, removed := unset(schedule, "cronjob"); removed {
+					record("schedule.cronjob", from, "")
+				}
+			}
+		}
+
+		if desired.Labels != nil {
+			if from, changed := setSequence(item, "labels", desired.Labels); changed {
+				record("labels", from, strings.Join(desired.Labels, ","))
+			}
+		}
+
+		if limit := desired.OpenPullRequestsLimit; limit != nil {
+			to := strconv.Itoa(*limit)
+			if from, changed := setScalar(item, "open-pull-requests-limit", to, "!!int"); changed {
+				record("open-pull-requests-limit", from, to)
+			}
+		}
+
+		if cm := desired.CommitMessage; cm != nil && cm.Prefix != "" {
+			if from, changed := setScalar(mapping(item, "commit-message"), "prefix", cm.Prefix, "!!str"); changed {
+				record("commit-message.prefix", from, cm.Prefix)
+			}
+		}
+	}
+	return changes
+}
+
+// mapping returns the mapping for key, adding an empty one if missing
+func mapping(m *yaml.Node, key string) *yaml.Node {
+	if v := lookup(m, key); v != nil && v.Kind == yaml.MappingNode {
+		return v
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	set(m, key, v)
+	return v
+}
+
+// setScalar sets key to value returning the previous value and
+// true if the value was changed
+func setScalar(m *yaml.Node, key, value, tag string) (string, bool) {
+	if v := lookup(m, key); v != nil && v.Kind == yaml.ScalarNode {
+		if v.Value == value {
+			return value, false
+		}
+		from := v.Value
+		v.Value, v.Tag, v.Style = value, tag, 0
+		return from, true
+	}
+	set(m, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+	return "", true
+}
+
+// setSequence sets key to a list of strings returning the previous
+// values joined with ',' and true if the values were changed
+func setSequence(m *yaml.Node, key string, values []string) (string, bool) {
+	existing := []string{}
+	v := lookup(m, key)
+	if v != nil && v.Kind == yaml.SequenceNode {
+		for _, item := range v.Content {
+			existing = append(existing, item.Value)
+		}
+		if strings.Join(existing, "\x00") == strings.Join(values, "\x00") {
+			return strings.Join(existing, ","), false
+		}
+	}
+
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, value := range values {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+	}
+	if v != nil {
+		seq.Style = v.Style
+	}
+	set(m, key, seq)
+	return strings.Join(existing, ","), true
+}
+
+// unset removes key from a mapping returning the previous value and
+// true if the key was removed
+func unset(m *yaml.Node, key string) (string, bool) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			from := m.Content[i+1].Value
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return from, true
+		}
+	}
+	return "", false
+}
+
+// set replaces the value of key in a mapping, or appends the key
+func set(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// defaultIndent is the indentation of a new dependabot configuration
+const defaultIndent = 2
+
+// indentation returns the smallest indentation used in data, or the
+// default if nothing is indented
+func indentation(data []byte) int {
+	indent := 0
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " ")
+		if len(trimmed) == 0 {
+			continue
+		}
+		if n := len(line) - len(trimmed); n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < defaultIndent {
+		return defaultIndent
+	}
+	return indent
+}
+
+// encode marshals the document with the given indentation
+func encode(doc *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
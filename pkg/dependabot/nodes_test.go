@@ -0,0 +1,247 @@
+package dependabot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_ApplyAllTo_Is_Ordered(t *testing.T) {
+	t.Parallel()
+
+	updates := Updates{}
+	updates.Add(newDefaultUpdate("npm", "/web"))
+	updates.Add(newDefaultUpdate("gomod", "/b"))
+	updates.Add(newDefaultUpdate("gomod", "/a"))
+	updates.Add(newDefaultUpdate("docker", "/"))
+
+	var first []byte
+	for i := 0; i < 10; i++ {
+		var doc yaml.Node
+		require.Nil(t, yaml.Unmarshal([]byte("version: 2\nupdates:\n"), &doc))
+		require.Nil(t, updates.ApplyAllTo(&doc))
+
+		out, err := yaml.Marshal(&doc)
+		require.Nil(t, err)
+		if first == nil {
+			first = out
+		}
+		require.Equal(t, string(first), string(out))
+	}
+
+	all := updates.ToArray()
+	require.Equal(t, "docker", all[0].PackageEcoSystem)
+	require.Equal(t, "/a", all[1].Directory)
+	require.Equal(t, "/b", all[2].Directory)
+	require.Equal(t, "npm", all[3].PackageEcoSystem)
+}
+
+func Test_ApplyAllTo_Adds_Missing_Keys(t *testing.T) {
+	t.Parallel()
+
+	updates := Updates{}
+	updates.Add(newDefaultUpdate("gomod", "/"))
+
+	for in, expected := range map[string]string{
+		"":                          "version: 2\nupdates:\n    - package-ecosystem: gomod\n",
+		"version: 2\n":              "version: 2\nupdates:\n    - package-ecosystem: gomod\n",
+		"# deps\nupdates:\n":        "version: 2\n# deps\nupdates:\n    - package-ecosystem: gomod\n",
+		"version: 2\nupdates: []\n": "version: 2\nupdates:\n    - package-ecosystem: gomod\n",
+	} {
+		var doc yaml.Node
+		require.Nil(t, yaml.Unmarshal([]byte(in), &doc))
+		require.Nil(t, updates.ApplyAllTo(&doc), in)
+
+		out, err := yaml.Marshal(&doc)
+		require.Nil(t, err)
+		require.Contains(t, string(out), expected, in)
+	}
+
+	var doc yaml.Node
+	require.Nil(t, yaml.Unmarshal([]byte("version: 2\nupdates: gomod\n"), &doc))
+	require.NotNil(t, updates.ApplyAllTo(&doc))
+}
+
+func Test_SortUpdates_Keeps_Comments(t *testing.T) {
+	t.Parallel()
+
+	in := `version: 2
+updates:
+  # the web app
+  - package-ecosystem: npm
+    directory: /web
+  - package-ecosystem: gomod
+    directory: /
+`
+	var doc yaml.Node
+	require.Nil(t, yaml.Unmarshal([]byte(in), &doc))
+
+	sortUpdates(&doc)
+
+	out, err := yaml.Marshal(&doc)
+	require.Nil(t, err)
+	require.Equal(t, `version: 2
+updates:
+    - package-ecosystem: gomod
+      directory: /
+    # the web app
+    - package-ecosystem: npm
+      directory: /web
+`, string(out))
+}
+
+func Test_PruneUpdates_Removes_Undetected(t *testing.T) {
+	t.Parallel()
+
+	in := `version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /web
+  # still here
+  - package-ecosystem: gomod
+    directory: /
+  # written by hand
+  - package-ecosystem: helm
+    directory: /charts/app
+`
+	var doc yaml.Node
+	require.Nil(t, yaml.Unmarshal([]byte(in), &doc))
+
+	detected := Updates{}
+	detected.Add(newDefaultUpdate("gomod", "/"))
+
+	removed := pruneUpdates(&doc, detected, Config{}.detectable)
+
+	require.Len(t, removed, 1)
+	require.Equal(t, "npm", removed[0].PackageEcoSystem)
+	require.Equal(t, "/web", removed[0].Directory)
+
+	out, err := yaml.Marshal(&doc)
+	require.Nil(t, err)
+	require.Equal(t, `version: 2
+updates:
+    # still here
+    - package-ecosystem: gomod
+      directory: /
+    # written by hand
+    - package-ecosystem: helm
+      directory: /charts/app
+`, string(out))
+
+	// unless a rule in .dependr.yml detects the ecosystem
+	config := Config{Ecosystems: []EcosystemRule{{Pattern: "Chart.yaml", Ecosystem: "helm"}}}
+	removed = pruneUpdates(&doc, detected, config.detectable)
+
+	require.Len(t, removed, 1)
+	require.Equal(t, "helm", removed[0].PackageEcoSystem)
+}
+
+func Test_ReconcileUpdates_Edits_In_Place(t *testing.T) {
+	t.Parallel()
+
+	in := `version: 2
+updates:
+  # the web app
+  - package-ecosystem: npm
+    directory: /web
+    schedule:
+      interval: monthly # too slow
+    labels: [deps]
+    reviewers:
+      - octocat
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: daily
+    labels: [deps, go]
+    open-pull-requests-limit: 5
+    commit-message:
+      prefix: deps
+  - package-ecosystem: docker
+    directory: /
+    schedule:
+      interval: weekly
+      day: monday
+      time: "09:00"
+    labels: [deps, go]
+    open-pull-requests-limit: 5
+    commit-message:
+      prefix: deps
+  - package-ecosystem: pip
+    directory: /
+    schedule:
+      interval: cron
+      cronjob: "0 9 * * *"
+    labels: [deps, go]
+    open-pull-requests-limit: 5
+    commit-message:
+      prefix: deps
+`
+	var doc yaml.Node
+	require.Nil(t, yaml.Unmarshal([]byte(in), &doc))
+
+	limit := 5
+	policy := Update{
+		Schedule:              Schedule{Interval: "daily"},
+		Labels:                []string{"deps", "go"},
+		OpenPullRequestsLimit: &limit,
+		CommitMessage:         &CommitMessage{Prefix: "deps"},
+	}
+	changes := reconcileUpdates(&doc, func(string) Update { return policy })
+
+	require.Equal(t, []Change{
+		{Ecosystem: "npm", Directory: "/web", Field: "schedule.interval", From: "monthly", To: "daily"},
+		{Ecosystem: "npm", Directory: "/web", Field: "labels", From: "deps", To: "deps,go"},
+		{Ecosystem: "npm", Directory: "/web", Field: "open-pull-requests-limit", From: "", To: "5"},
+		{Ecosystem: "npm", Directory: "/web", Field: "commit-message.prefix", From: "", To: "deps"},
+		{Ecosystem: "docker", Directory: "/", Field: "schedule.interval", From: "weekly", To: "daily"},
+		{Ecosystem: "docker", Directory: "/", Field: "schedule.day", From: "monday", To: ""},
+		{Ecosystem: "pip", Directory: "/", Field: "schedule.interval", From: "cron", To: "daily"},
+		{Ecosystem: "pip", Directory: "/", Field: "schedule.cronjob", From: "0 9 * * *", To: ""},
+	}, changes)
+
+	out, err := yaml.Marshal(&doc)
+	require.Nil(t, err)
+	require.Equal(t, `version: 2
+updates:
+    # the web app
+    - package-ecosystem: npm
+      directory: /web
+      schedule:
+        interval: daily # too slow
+      labels: [deps, go]
+      reviewers:
+        - octocat
+      open-pull-requests-limit: 5
+      commit-message:
+        prefix: deps
+    - package-ecosystem: gomod
+      directory: /
+      schedule:
+        interval: daily
+      labels: [deps, go]
+      open-pull-requests-limit: 5
+      commit-message:
+        prefix: deps
+    - package-ecosystem: docker
+      directory: /
+      schedule:
+        interval: daily
+        time: "09:00"
+      labels: [deps, go]
+      open-pull-requests-limit: 5
+      commit-message:
+        prefix: deps
+    - package-ecosystem: pip
+      directory: /
+      schedule:
+        interval: daily
+      labels: [deps, go]
+      open-pull-requests-limit: 5
+      commit-message:
+        prefix: deps
+`, string(out))
+
+	require.Empty(t, reconcileUpdates(&doc, func(string) Update { return policy }))
+}
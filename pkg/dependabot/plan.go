@@ -0,0 +1,186 @@
+package dependabot
+
+import (
+	"io/fs"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type (
+	// ScanOptions controls how the dependabot configuration is generated
+	ScanOptions struct {
+		// Sort re-orders all of the updates, including existing ones,
+		// by ecosystem then directory
+		Sort bool
+		// Prune removes existing updates that were not detected
+		Prune bool
+		// Reconcile edits existing updates to match the configured defaults
+		Reconcile bool
+		// Defaults are applied to generated and reconciled updates
+		Defaults *Defaults
+		// Groups adds groups to generated updates, those configured in
+		// .dependr.yml or one for minor and patch updates and one for
+		// major updates
+		Groups bool
+		// Collapse merges generated updates with the same ecosystem and
+		// settings into a single update using directories
+		Collapse bool
+		// Globs collapses updates and replaces their directories with a
+		// glob (e.g. /services/*) where it matches exactly the same
+		// directories in the repository
+		Globs bool
+		// TrackedOnly only scans files tracked by git
+		TrackedOnly bool
+		// Detectors used to find ecosystems, defaults to the built in detectors
+		Detectors *Detectors
+	}
+
+	// Plan holds the result of scanning a repository before
+	// anything has been written to disk.
+	Plan struct {
+		// Root is the path to the repository on disk, empty if not on disk
+		Root string
+		// Path is the local path (from the root) to the dependabot configuration
+		Path string
+		// Exists is true if the dependabot configuration was already present
+		Exists bool
+		// Mode is the permissions of the existing dependabot configuration
+		// or the permissions to create it with
+		Mode fs.FileMode
+		// Original is the content of the existing dependabot configuration
+		Original []byte
+		// Detected holds every manifest found in the repository
+		Detected []Match
+		// Present holds the detected updates already in the configuration
+		Present []Update
+		// Added holds the detected updates missing from the existing configuration
+		Added []Update
+		// Pruned holds the existing updates removed as they were not detected
+		Pruned []Update
+		// Reconciled holds the changes made to existing updates
+		Reconciled []Change
+		// Sorted is true if sorting changed the order of the updates
+		Sorted bool
+		// Generated is the resulting dependabot configuration or nil if
+		// there is nothing to write
+		Generated []byte
+	}
+
+	// Change is a field of an existing update changed by reconciling
+	Change struct {
+		Ecosystem string `json:"ecosystem"`
+		Directory string `json:"directory"`
+		Field     string `json:"field"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+	}
+
+	// Report is a machine readable summary of a Plan
+	Report struct {
+		Root     string         `json:"root"`
+		Path     string         `json:"path"`
+		Existed  bool           `json:"existed"`
+		Changed  bool           `json:"changed"`
+		Detected []Match        `json:"detected"`
+		Present  []ReportUpdate `json:"present"`
+		Added    []ReportUpdate `json:"added"`
+		Pruned   []ReportUpdate `json:"pruned"`
+		// Reconciled holds each field changed in an existing update
+		Reconciled []Change `json:"reconciled"`
+		// Diff is only set when changes are not written
+		Diff string `json:"diff,omitempty"`
+	}
+
+	// ReportUpdate identifies an update in a Report
+	ReportUpdate struct {
+		Ecosystem   string   `json:"ecosystem"`
+		Directory   string   `json:"directory,omitempty"`
+		Directories []string `json:"directories,omitempty"`
+	}
+)
+
+// Report summarises the plan
+func (p *Plan) Report() Report {
+	return Report{
+		Root:     p.Root,
+		Path:     p.Path,
+		Existed:  p.Exists,
+		Changed:  p.Changed(),
+		Detected: append([]Match{}, p.Detected...),
+		Present:  reportUpdates(p.Present),
+		Added:    reportUpdates(p.Added),
+		Pruned:   reportUpdates(p.Pruned),
+		// never nil so the JSON holds an empty list
+		Reconciled: append([]Change{}, p.Reconciled...),
+	}
+}
+
+// Changed returns true if the plan adds, prunes, reconciles or sorts
+// updates. Formatting differences alone are not a change.
+func (p *Plan) Changed() bool {
+	return p.Generated != nil &&
+		(len(p.Added) > 0 || len(p.Pruned) > 0 || len(p.Reconciled) > 0 || p.Sorted)
+}
+
+// reportUpdates converts updates for a Report, never returning nil
+// so the JSON holds an empty list
+func reportUpdates(updates []Update) []ReportUpdate {
+	all := []ReportUpdate{}
+	for _, u := range updates {
+		all = append(all, ReportUpdate{
+			Ecosystem:   u.PackageEcoSystem,
+			Directory:   u.Directory,
+			Directories: u.Directories,
+		})
+	}
+	return all
+}
+
+// WriteFile saves the generated dependabot configuration to a file on
+// disk rather than in the repository. The file is created with the
+// permissions of the existing configuration.
+func (p *Plan) WriteFile(name string) error {
+	if p.Generated == nil {
+		return nil
+	}
+	if err := writeFile(name, p.Generated, p.Mode); err != nil {
+		return errors.Wrapf(err, "error writing dependabot file: %s", name)
+	}
+	return nil
+}
+
+// Diff returns a unified diff between the existing dependabot configuration
+// (or an empty file if missing) and the generated configuration, nothing
+// is returned for an existing configuration that is not changed.
+func (p *Plan) Diff() (string, error) {
+
+	if p.Generated == nil || (p.Exists && !p.Changed()) {
+		return "", nil
+	}
+
+	from := "a/" + p.Path
+	if !p.Exists {
+		from = "/dev/null"
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(p.Original),
+		B:        splitLines(p.Generated),
+		FromFile: from,
+		ToFile:   "b/" + p.Path,
+		Context:  3, //nolint:gomnd
+	})
+}
+
+// splitLines returns the lines of data, each terminated with a newline
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
@@ -0,0 +1,237 @@
+package dependabot
+
+import "sort"
+
+// Ecosystem describes a package-ecosystem supported by dependabot
+type Ecosystem struct {
+	// Name is the package-ecosystem value dependabot accepts
+	Name string
+	// Aliases are other names the ecosystem is known by (e.g. yarn),
+	// accepted in dependr configuration but never written
+	Aliases []string
+	// Manifests are the file patterns detected by name alone, following
+	// the same rules as those in .dependr.yml
+	Manifests []string
+	// Inspected are the file patterns the built in detectors match before
+	// reading the file or those around it (e.g. workspaces)
+	Inspected []string
+	// Vendor is true if dependabot can update vendored dependencies
+	Vendor bool
+	// VersioningStrategies lists the versioning-strategy values supported
+	VersioningStrategies []string
+	// Directories is true if an update may use directories
+	Directories bool
+	// RootOnly is true if the ecosystem can only be updated from the
+	// root of the repository
+	RootOnly bool
+}
+
+var (
+	// https://docs.github.com/en/code-security/dependabot/dependabot-version-updates/configuration-options-for-the-dependabot.yml-file#package-ecosystem
+	registry = []Ecosystem{
+		{
+			Name:        "bun",
+			Inspected:   []string{"bun.lock", "bun.lockb", "package.json"},
+			Directories: true,
+		},
+		{
+			Name:                 "bundler",
+			Aliases:              []string{"ruby", "rubygems"},
+			Manifests:            []string{"Gemfile", "Gemfile.lock", "*.gemspec"},
+			Vendor:               true,
+			VersioningStrategies: []string{"auto", "increase", "increase-if-necessary", "lockfile-only"},
+			Directories:          true,
+		},
+		{
+			Name:                 "cargo",
+			Aliases:              []string{"rust"},
+			Inspected:            []string{"Cargo.toml", "Cargo.lock"},
+			VersioningStrategies: []string{"auto", "lockfile-only"},
+			Directories:          true,
+		},
+		{
+			Name:                 "composer",
+			Aliases:              []string{"php"},
+			Manifests:            []string{"composer.json", "composer.lock"},
+			VersioningStrategies: []string{"auto", "increase", "increase-if-necessary", "lockfile-only", "widen"},
+			Directories:          true,
+		},
+		{
+			Name:        "devcontainers",
+			Directories: true,
+		},
+		{
+			Name: "docker",
+			Manifests: []string{
+				"Dockerfile", "Dockerfile.*", "*.dockerfile", "*.Dockerfile", "Containerfile", "Containerfile.*",
+			},
+			Directories: true,
+		},
+		{
+			Name:        "docker-compose",
+			Directories: true,
+		},
+		{
+			Name:        "dotnet-sdk",
+			Directories: true,
+		},
+		{
+			Name:        "elm",
+			Manifests:   []string{"elm-package.json"},
+			Directories: true,
+		},
+		{
+			Name:        "github-actions",
+			Aliases:     []string{"actions"},
+			Inspected:   []string{".github/workflows/*.yml", ".github/workflows/*.yaml"},
+			Directories: true,
+		},
+		{
+			Name:      "gitsubmodule",
+			Aliases:   []string{"submodule"},
+			Manifests: []string{".gitmodules"},
+			RootOnly:  true,
+		},
+		{
+			Name:        "gomod",
+			Aliases:     []string{"go", "golang"},
+			Inspected:   []string{"go.mod", "go.sum"},
+			Vendor:      true,
+			Directories: true,
+		},
+		{
+			Name: "gradle",
+			Inspected: []string{
+				"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts",
+				"gradle/libs.versions.toml", "gradle/wrapper/gradle-wrapper.properties",
+			},
+			Directories: true,
+		},
+		{
+			Name:        "helm",
+			Directories: true,
+		},
+		{
+			Name:        "maven",
+			Inspected:   []string{"pom.xml"},
+			Directories: true,
+		},
+		{
+			Name:                 "mix",
+			Aliases:              []string{"hex", "elixir"},
+			Manifests:            []string{"mix.exs", "mix.lock"},
+			VersioningStrategies: []string{"auto", "lockfile-only"},
+			Directories:          true,
+		},
+		{
+			Name:    "npm",
+			Aliases: []string{"yarn", "pnpm"},
+			Inspected: []string{
+				"package.json", "package-lock.json", "yarn.lock", ".yarnrc.yml", "pnpm-lock.yaml", "pnpm-workspace.yaml",
+			},
+			VersioningStrategies: []string{"auto", "increase", "increase-if-necessary", "lockfile-only", "widen"},
+			Directories:          true,
+		},
+		{
+			Name:        "nuget",
+			Aliases:     []string{"dotnet"},
+			Manifests:   []string{"*.csproj", "*.vbproj", "*.nuspec", "*.vcxproj", "*.fsproj", "packages.config"},
+			Directories: true,
+		},
+		{
+			Name:    "pip",
+			Aliases: []string{"pipenv", "poetry", "python"},
+			Manifests: []string{
+				"requirements.txt", "requirements-*.txt", "requirements/*.txt",
+				"Pipfile", "Pipfile.lock", "poetry.lock", "setup.py",
+			},
+			Inspected:            []string{"pyproject.toml", "setup.cfg"},
+			VersioningStrategies: []string{"auto", "increase", "increase-if-necessary", "lockfile-only"},
+			Directories:          true,
+		},
+		{
+			Name:                 "pub",
+			Aliases:              []string{"dart", "flutter"},
+			VersioningStrategies: []string{"auto", "increase", "increase-if-necessary", "widen"},
+			Directories:          true,
+		},
+		{
+			Name:        "swift",
+			Directories: true,
+		},
+		{
+			Name:        "terraform",
+			Manifests:   []string{".terraform.lock.hcl"},
+			Directories: true,
+		},
+		{
+			Name:        "uv",
+			Manifests:   []string{"uv.lock"},
+			Inspected:   []string{"pyproject.toml"},
+			Directories: true,
+		},
+	}
+
+	// wellKnown matches the manifests detected by name alone
+	wellKnown = newRules(registry)
+)
+
+// Ecosystems returns the ecosystems supported by dependabot ordered by name
+func Ecosystems() []Ecosystem {
+	all := append([]Ecosystem{}, registry...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// LookupEcosystem returns the ecosystem for a name or one of its aliases
+func LookupEcosystem(name string) (Ecosystem, bool) {
+	for _, e := range registry {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	for _, e := range registry {
+		for _, alias := range e.Aliases {
+			if alias == name {
+				return e, true
+			}
+		}
+	}
+	return Ecosystem{}, false
+}
+
+// SupportsVersioningStrategy returns true if the versioning-strategy can
+// be used with the ecosystem
+func (e Ecosystem) SupportsVersioningStrategy(strategy string) bool {
+	for _, s := range e.VersioningStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// inspected returns the update directory if the slash separated path
+// matches one of the inspected patterns of the named ecosystems
+func inspected(p string, names ...string) (string, bool) {
+	for _, name := range names {
+		e, _ := LookupEcosystem(name)
+		for _, pattern := range e.Inspected {
+			if dir, ok := (rule{pattern: pattern}).match(p); ok {
+				return dir, true
+			}
+		}
+	}
+	return "", false
+}
+
+// newRules returns the rules matching the manifests of the ecosystems
+func newRules(all []Ecosystem) ecosystems {
+	e := ecosystems{}
+	for _, ecosystem := range all {
+		for _, pattern := range ecosystem.Manifests {
+			e.rules = append(e.rules, rule{pattern: pattern, ecosystem: ecosystem.Name})
+		}
+	}
+	return e
+}
@@ -0,0 +1,103 @@
+package dependabot
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LookupEcosystem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		ecosystem string
+		found     bool
+	}{
+		{name: "gomod", ecosystem: "gomod", found: true},
+		{name: "yarn", ecosystem: "npm", found: true},
+		{name: "hex", ecosystem: "mix", found: true},
+		{name: "gradel", found: false},
+		{name: "", found: false},
+	}
+
+	for _, tt := range tests {
+		e, found := LookupEcosystem(tt.name)
+		require.Equal(t, tt.found, found, tt.name)
+		require.Equal(t, tt.ecosystem, e.Name, tt.name)
+	}
+}
+
+func Test_Ecosystems_Are_Consistent(t *testing.T) {
+	t.Parallel()
+
+	names := map[string]bool{}
+	for _, e := range Ecosystems() {
+		require.False(t, names[e.Name], "duplicate %s", e.Name)
+		names[e.Name] = true
+
+		for _, pattern := range append(append([]string{}, e.Manifests...), e.Inspected...) {
+			_, err := path.Match(pattern, "")
+			require.Nil(t, err, pattern)
+		}
+	}
+
+	for _, e := range Ecosystems() {
+		for _, alias := range e.Aliases {
+			require.False(t, names[alias], "alias %s is an ecosystem", alias)
+			names[alias] = true
+		}
+	}
+}
+
+func Test_Plan_Only_Writes_Known_Ecosystems(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"flake.nix": {}, "yarn.config": {}}
+	n, err := LoadOrCreateFS(fsys, ".")
+	require.Nil(t, err)
+
+	detect := func(ecosystem string) *Detectors {
+		d := &Detectors{}
+		d.Register(DetectorFunc(func(p string, _ fs.FS) ([]Match, error) {
+			return []Match{{Ecosystem: ecosystem, Directory: "/", Manifest: p}}, nil
+		}))
+		return d
+	}
+
+	_, err = n.Plan(ScanOptions{Detectors: detect("nix")})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unknown ecosystem nix")
+
+	// aliases are written using the ecosystem name
+	plan, err := n.Plan(ScanOptions{Detectors: detect("yarn")})
+	require.Nil(t, err)
+	require.Len(t, plan.Added, 1)
+	require.Equal(t, "npm", plan.Added[0].PackageEcoSystem)
+}
+
+func Test_Ecosystems_Patterns_Are_Detected(t *testing.T) {
+	t.Parallel()
+
+	// content for the files that are only detected when they declare a project
+	content := map[string]string{
+		"pyproject.toml": "[project]\n",
+		"setup.cfg":      "[metadata]\n",
+	}
+
+	d := NewDetectors()
+	for _, e := range Ecosystems() {
+		for _, pattern := range append(append([]string{}, e.Manifests...), e.Inspected...) {
+			name := strings.ReplaceAll(pattern, "*", "x")
+			fsys := fstest.MapFS{name: {Data: []byte(content[path.Base(name)])}}
+
+			matches, err := d.Detect(name, fsys)
+			require.Nil(t, err, name)
+			require.NotEmpty(t, matches, "%s %s", e.Name, pattern)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package dependabot
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// The types below model version 2 of the dependabot configuration file
+// https://docs.github.com/en/code-security/dependabot/dependabot-version-updates/configuration-options-for-the-dependabot.yml-file
+//
+//nolint:lll
+type (
+	// Doc is the root of a dependabot configuration file
+	Doc struct {
+		Version              int                 `yaml:"version"`
+		EnableBetaEcosystems bool                `yaml:"enable-beta-ecosystems,omitempty"`
+		Registries           map[string]Registry `yaml:"registries,omitempty"`
+		Updates              []Update            `yaml:"updates"`
+	}
+
+	// Registry holds the details of a private registry
+	Registry struct {
+		Type                 string `yaml:"type"`
+		URL                  string `yaml:"url,omitempty"`
+		Username             string `yaml:"username,omitempty"`
+		Password             string `yaml:"password,omitempty"`
+		Key                  string `yaml:"key,omitempty"`
+		Token                string `yaml:"token,omitempty"`
+		ReplacesBase         bool   `yaml:"replaces-base,omitempty"`
+		Organization         string `yaml:"organization,omitempty"`
+		Repo                 string `yaml:"repo,omitempty"`
+		AuthKey              string `yaml:"auth-key,omitempty"`
+		PublicKeyFingerprint string `yaml:"public-key-fingerprint,omitempty"`
+	}
+
+	// Update configures how dependabot maintains a single package ecosystem
+	Update struct {
+		PackageEcoSystem              string                 `yaml:"package-ecosystem"`
+		Directory                     string                 `yaml:"directory,omitempty"`
+		Directories                   []string               `yaml:"directories,omitempty"`
+		Schedule                      Schedule               `yaml:"schedule"`
+		Allow                         []Allow                `yaml:"allow,omitempty"`
+		Assignees                     []string               `yaml:"assignees,omitempty"`
+		CommitMessage                 *CommitMessage         `yaml:"commit-message,omitempty"`
+		Groups                        map[string]Group       `yaml:"groups,omitempty"`
+		Ignore                        []Ignore               `yaml:"ignore,omitempty"`
+		InsecureExternalCodeExecution string                 `yaml:"insecure-external-code-execution,omitempty"`
+		Labels                        []string               `yaml:"labels,omitempty"`
+		Milestone                     int                    `yaml:"milestone,omitempty"`
+		OpenPullRequestsLimit         *int                   `yaml:"open-pull-requests-limit,omitempty"`
+		PullRequestBranchName         *PullRequestBranchName `yaml:"pull-request-branch-name,omitempty"`
+		RebaseStrategy                string                 `yaml:"rebase-strategy,omitempty"`
+		Registries                    Registries             `yaml:"registries,omitempty"`
+		Reviewers                     []string               `yaml:"reviewers,omitempty"`
+		TargetBranch                  string                 `yaml:"target-branch,omitempty"`
+		Vendor                        bool                   `yaml:"vendor,omitempty"`
+		VersioningStrategy            string                 `yaml:"versioning-strategy,omitempty"`
+	}
+
+	// Schedule is how often dependabot checks for updates
+	Schedule struct {
+		Interval string `yaml:"interval"`
+		Day      string `yaml:"day,omitempty"`
+		Time     string `yaml:"time,omitempty"`
+		Timezone string `yaml:"timezone,omitempty"`
+		Cronjob  string `yaml:"cronjob,omitempty"`
+	}
+
+	// Allow restricts updates to the matching dependencies
+	Allow struct {
+		DependencyName string `yaml:"dependency-name,omitempty"`
+		DependencyType string `yaml:"dependency-type,omitempty"`
+	}
+
+	// Ignore excludes dependencies or versions from updates
+	Ignore struct {
+		DependencyName string   `yaml:"dependency-name"`
+		Versions       []string `yaml:"versions,omitempty"`
+		UpdateTypes    []string `yaml:"update-types,omitempty"`
+	}
+
+	// CommitMessage configures the commit message of pull requests
+	CommitMessage struct {
+		Prefix            string `yaml:"prefix,omitempty"`
+		PrefixDevelopment string `yaml:"prefix-development,omitempty"`
+		Include           string `yaml:"include,omitempty"`
+	}
+
+	// Group collects updates for multiple dependencies into a single pull request
+	Group struct {
+		AppliesTo       string   `yaml:"applies-to,omitempty"`
+		DependencyType  string   `yaml:"dependency-type,omitempty"`
+		Patterns        []string `yaml:"patterns,omitempty"`
+		ExcludePatterns []string `yaml:"exclude-patterns,omitempty"`
+		UpdateTypes     []string `yaml:"update-types,omitempty"`
+	}
+
+	// PullRequestBranchName configures the branch name of pull requests
+	PullRequestBranchName struct {
+		Separator string `yaml:"separator"`
+	}
+
+	// Registries lists the registries an update may use, a single
+	// entry of "*" allows all of the registries.
+	Registries []string
+)
+
+// UnmarshalYAML accepts either a list of registry names or "*"
+func (r *Registries) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*r = Registries{value.Value}
+		return nil
+	}
+	var all []string
+	if err := value.Decode(&all); err != nil {
+		return err
+	}
+	*r = all
+	return nil
+}
+
+// MarshalYAML writes "*" as a scalar otherwise a list of registry names
+func (r Registries) MarshalYAML() (interface{}, error) {
+	if len(r) == 1 && r[0] == "*" {
+		return "*", nil
+	}
+	return []string(r), nil
+}
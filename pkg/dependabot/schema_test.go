@@ -0,0 +1,79 @@
+package dependabot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_Doc_Round_Trip(t *testing.T) {
+	t.Parallel()
+
+	in := `version: 2
+registries:
+    npm-github:
+        type: npm-registry
+        url: https://npm.pkg.github.com
+        token: ${{secrets.MY_GITHUB_PERSONAL_TOKEN}}
+        replaces-base: true
+updates:
+    - package-ecosystem: npm
+      directories:
+        - /web
+        - /admin
+      schedule:
+        interval: weekly
+        day: monday
+        time: "09:00"
+        timezone: Europe/London
+      allow:
+        - dependency-type: production
+      assignees:
+        - octocat
+      commit-message:
+        prefix: npm
+        include: scope
+      groups:
+        dev:
+            dependency-type: development
+            update-types:
+                - minor
+                - patch
+      ignore:
+        - dependency-name: express
+          versions:
+            - 4.x
+      labels:
+        - dependencies
+      milestone: 4
+      open-pull-requests-limit: 0
+      rebase-strategy: disabled
+      registries: '*'
+      reviewers:
+        - my-org/team
+      target-branch: develop
+      versioning-strategy: increase
+    - package-ecosystem: gomod
+      directory: /
+      schedule:
+        interval: daily
+      registries:
+        - goproxy
+      vendor: true
+`
+	var doc Doc
+	require.Nil(t, yaml.Unmarshal([]byte(in), &doc))
+
+	require.Equal(t, 2, doc.Version)
+	require.True(t, doc.Registries["npm-github"].ReplacesBase)
+	require.Equal(t, Registries{"*"}, doc.Updates[0].Registries)
+	require.Equal(t, 0, *doc.Updates[0].OpenPullRequestsLimit)
+	require.Equal(t, []string{"/web", "/admin"}, doc.Updates[0].Directories)
+	require.Equal(t, Registries{"goproxy"}, doc.Updates[1].Registries)
+	require.True(t, doc.Updates[1].Vendor)
+
+	out, err := yaml.Marshal(&doc)
+	require.Nil(t, err)
+	require.Equal(t, in, string(out))
+}
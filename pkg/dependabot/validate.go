@@ -0,0 +1,307 @@
+package dependabot
+
+import (
+	"bytes"
+	_ "embed" // used to embed the dependabot schema
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // validate timezones without relying on the host
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Problem describes an issue found validating a dependabot file
+	Problem struct {
+		Line    int
+		Column  int
+		Message string
+	}
+)
+
+var (
+	//go:embed schemas/dependabot-2.0.json
+	dependabotSchema string
+
+	// yaml syntax errors only report the line e.g. "yaml: line 3: ..."
+	syntaxErrorLine = regexp.MustCompile(`line (\d+):`)
+)
+
+// dependabotSchemaName identifies the embedded schema, it is written by
+// hand so is deliberately not the schemastore URL
+const dependabotSchemaName = "dependabot-2.0.json"
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+}
+
+// Validate checks the dependabot file against the dependabot schema
+// and for mistakes the schema can not catch e.g. directories missing
+// from the repository. The problems are ordered by position in the file.
+func (n *node) Validate() ([]Problem, error) {
+
+	if !n.repo.dependabotFileExists {
+		return nil, ErrMissingConfigFile
+	}
+
+	data, err := fs.ReadFile(n.repo.fsys, n.repo.dependabotFilePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading file: %s", n.repo.dependabotFilePath)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Problem{syntaxProblem(err)}, nil
+	}
+
+	problems, err := validateSchema(&doc)
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, n.validateUpdates(&doc)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+	return problems, nil
+}
+
+// syntaxProblem converts a yaml parsing error into a Problem
+func syntaxProblem(err error) Problem {
+	p := Problem{Line: 1, Column: 1, Message: err.Error()}
+	if m := syntaxErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		p.Line, _ = strconv.Atoi(m[1])
+	}
+	return p
+}
+
+// validateSchema checks the document against the embedded JSON schema
+func validateSchema(doc *yaml.Node) ([]Problem, error) {
+
+	if len(doc.Content) == 0 {
+		return []Problem{{Line: 1, Column: 1, Message: "empty dependabot file"}}, nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(dependabotSchemaName, strings.NewReader(dependabotSchema)); err != nil {
+		return nil, errors.Wrap(err, "error loading schema")
+	}
+	schema, err := compiler.Compile(dependabotSchemaName)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling schema")
+	}
+
+	// the schema validates JSON so round trip the YAML
+	var v interface{}
+	if err := doc.Decode(&v); err != nil {
+		return nil, errors.Wrap(err, "error decoding yaml")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return []Problem{{Line: 1, Column: 1, Message: "dependabot file can not be represented as JSON"}}, nil
+	}
+	var instance interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&instance); err != nil {
+		return nil, errors.Wrap(err, "error decoding json")
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return nil, errors.Wrap(err, "error validating schema")
+	}
+
+	problems := []Problem{}
+	for _, leaf := range leafErrors(ve) {
+		at := nodeAt(doc.Content[0], leaf.InstanceLocation)
+		msg := leaf.Message
+		if leaf.InstanceLocation != "" {
+			msg = fmt.Sprintf("%s: %s", leaf.InstanceLocation, leaf.Message)
+		}
+		problems = append(problems, Problem{
+			Line:    at.Line,
+			Column:  at.Column,
+			Message: msg,
+		})
+	}
+	return problems, nil
+}
+
+// leafErrors returns the most specific validation errors
+func leafErrors(ve *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(ve.Causes) == 0 {
+		return []*jsonschema.ValidationError{ve}
+	}
+	all := []*jsonschema.ValidationError{}
+	for _, c := range ve.Causes {
+		all = append(all, leafErrors(c)...)
+	}
+	return all
+}
+
+// nodeAt follows a JSON pointer through the yaml nodes returning the
+// deepest node found.
+func nodeAt(n *yaml.Node, pointer string) *yaml.Node {
+	for _, token := range strings.Split(pointer, "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		var next *yaml.Node
+		switch n.Kind {
+		case yaml.MappingNode:
+			next = lookup(n, token)
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(token); err == nil && i < len(n.Content) {
+				next = n.Content[i]
+			}
+		}
+		if next == nil {
+			return n
+		}
+		n = next
+	}
+	return n
+}
+
+// validateUpdates checks the updates for problems the schema can not express
+func (n *node) validateUpdates(doc *yaml.Node) []Problem {
+
+	seq := findUpdates(doc)
+	if seq == nil {
+		return nil
+	}
+
+	problems := []Problem{}
+	add := func(at *yaml.Node, format string, args ...interface{}) {
+		problems = append(problems, Problem{Line: at.Line, Column: at.Column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := map[string]bool{}
+
+	for _, item := range seq.Content {
+		var u Update
+		if err := item.Decode(&u); err != nil {
+			// reported by the schema
+			continue
+		}
+
+		ecosystem, known := LookupEcosystem(u.PackageEcoSystem)
+		if eco := lookup(item, "package-ecosystem"); eco != nil {
+			switch {
+			case !known:
+				add(eco, "unknown package-ecosystem %q", u.PackageEcoSystem)
+			case ecosystem.Name != u.PackageEcoSystem:
+				add(eco, "unknown package-ecosystem %q, use %q", u.PackageEcoSystem, ecosystem.Name)
+			}
+		}
+		if known {
+			problems = append(problems, validateFeatures(item, ecosystem, u)...)
+		}
+
+		for _, dir := range directoryNodes(item) {
+			if !isGlob(dir.Value) && !pathExists(n.repo.fsys, dir.Value) {
+				add(dir, "directory %q does not exist", dir.Value)
+			}
+
+			key := fmt.Sprintf("%s %s %s", u.PackageEcoSystem, path.Clean("/"+dir.Value), u.TargetBranch)
+			if seen[key] {
+				add(dir, "duplicate update for package-ecosystem %q, directory %q and target-branch %q",
+					u.PackageEcoSystem, dir.Value, u.TargetBranch)
+			}
+			seen[key] = true
+		}
+
+		if schedule := lookup(item, "schedule"); schedule != nil {
+			problems = append(problems, validateSchedule(schedule, u.Schedule)...)
+		}
+	}
+	return problems
+}
+
+// directoryNodes returns the directory or directories of an update
+func directoryNodes(item *yaml.Node) []*yaml.Node {
+	if dir := lookup(item, "directory"); dir != nil && dir.Kind == yaml.ScalarNode {
+		return []*yaml.Node{dir}
+	}
+	all := []*yaml.Node{}
+	if dirs := lookup(item, "directories"); dirs != nil && dirs.Kind == yaml.SequenceNode {
+		for _, d := range dirs.Content {
+			if d.Kind == yaml.ScalarNode {
+				all = append(all, d)
+			}
+		}
+	}
+	return all
+}
+
+// validateSchedule checks the combination of schedule values
+func validateSchedule(n *yaml.Node, s Schedule) []Problem {
+	problems := []Problem{}
+	add := func(key, format string, args ...interface{}) {
+		at := n
+		if v := lookup(n, key); v != nil {
+			at = v
+		}
+		problems = append(problems, Problem{Line: at.Line, Column: at.Column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Day != "" && s.Interval != "weekly" {
+		add("day", "schedule.day is only used with a weekly interval")
+	}
+	if s.Interval == "cron" && s.Cronjob == "" {
+		add("interval", "schedule.cronjob is required with a cron interval")
+	}
+	if s.Cronjob != "" && s.Interval != "cron" {
+		add("cronjob", "schedule.cronjob is only used with a cron interval")
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil || s.Timezone == "Local" {
+			add("timezone", "unknown schedule.timezone %q", s.Timezone)
+		}
+	}
+	return problems
+}
+
+// validateFeatures checks the update only uses features the ecosystem supports
+func validateFeatures(item *yaml.Node, e Ecosystem, u Update) []Problem {
+	problems := []Problem{}
+	add := func(key, format string, args ...interface{}) {
+		if at := lookup(item, key); at != nil {
+			problems = append(problems, Problem{Line: at.Line, Column: at.Column, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	if u.Vendor && !e.Vendor {
+		add("vendor", "vendor is not supported by package-ecosystem %q", e.Name)
+	}
+	if u.VersioningStrategy != "" && !e.SupportsVersioningStrategy(u.VersioningStrategy) {
+		add("versioning-strategy", "versioning-strategy %q is not supported by package-ecosystem %q", u.VersioningStrategy, e.Name)
+	}
+	if len(u.Directories) > 0 && !e.Directories {
+		add("directories", "directories is not supported by package-ecosystem %q", e.Name)
+	}
+	if e.RootOnly && u.Directory != "" && path.Clean("/"+u.Directory) != "/" {
+		add("directory", "package-ecosystem %q must use the root directory", e.Name)
+	}
+	return problems
+}
@@ -0,0 +1,162 @@
+package dependabot
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func validate(t *testing.T, content string) []Problem {
+	t.Helper()
+
+	fsys := fstest.MapFS{
+		".github/dependabot.yml": {Data: []byte(content)},
+		"web/package.json":       {},
+	}
+
+	n, err := LoadFS(fsys, ".")
+	require.Nil(t, err)
+
+	problems, err := n.Validate()
+	require.Nil(t, err)
+	return problems
+}
+
+func Test_Validate_Valid_File(t *testing.T) {
+	t.Parallel()
+
+	problems := validate(t, `version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /web
+    schedule:
+      interval: weekly
+      day: monday
+      timezone: Europe/London
+`)
+	require.Empty(t, problems)
+}
+
+func Test_Validate_Newer_Options(t *testing.T) {
+	t.Parallel()
+
+	problems := validate(t, `version: 2
+multi-ecosystem-groups:
+  web:
+    schedule:
+      interval: weekly
+updates:
+  - package-ecosystem: npm
+    directory: /web
+    schedule:
+      interval: weekly
+    cooldown:
+      default-days: 5
+    exclude-paths:
+      - web/vendor/**
+  - package-ecosystem: npm
+    directory: /web
+    target-branch: next
+    multi-ecosystem-group: web
+    patterns: ["*"]
+`)
+	require.Empty(t, problems)
+
+	problems = validate(t, `version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /web
+    schedule:
+      interval: weekly
+    cooldown:
+      default-days: 0
+`)
+	require.Len(t, problems, 1)
+	require.Equal(t, 8, problems[0].Line)
+}
+
+func Test_Validate_Reports_Schema_Problems_With_Positions(t *testing.T) {
+	t.Parallel()
+
+	problems := validate(t, `version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: fortnightly
+    labelz:
+      - deps
+`)
+	require.Len(t, problems, 2)
+	require.Equal(t, 3, problems[0].Line)
+	require.Contains(t, problems[0].Message, "labelz")
+	require.Equal(t, 6, problems[1].Line)
+	require.Equal(t, 17, problems[1].Column)
+}
+
+func Test_Validate_Reports_Semantic_Problems(t *testing.T) {
+	t.Parallel()
+
+	problems := validate(t, `version: 2
+updates:
+  - package-ecosystem: gradel
+    directory: /
+    schedule:
+      interval: daily
+      day: monday
+      timezone: Mars/Olympus
+  - package-ecosystem: npm
+    directory: /missing
+    schedule:
+      interval: weekly
+  - package-ecosystem: npm
+    directory: /missing/
+    schedule:
+      interval: weekly
+`)
+	require.Equal(t, []Problem{
+		{Line: 3, Column: 24, Message: `unknown package-ecosystem "gradel"`},
+		{Line: 7, Column: 12, Message: "schedule.day is only used with a weekly interval"},
+		{Line: 8, Column: 17, Message: `unknown schedule.timezone "Mars/Olympus"`},
+		{Line: 10, Column: 16, Message: `directory "/missing" does not exist`},
+		{Line: 14, Column: 16, Message: `directory "/missing/" does not exist`},
+		{Line: 14, Column: 16, Message: `duplicate update for package-ecosystem "npm", directory "/missing/" and target-branch ""`},
+	}, problems)
+}
+
+func Test_Validate_Reports_Syntax_Errors(t *testing.T) {
+	t.Parallel()
+
+	problems := validate(t, "version: 2\nupdates:\n  - a: b\n c: d\n")
+	require.Len(t, problems, 1)
+	require.Equal(t, 3, problems[0].Line)
+}
+
+func Test_Validate_Reports_Unsupported_Features(t *testing.T) {
+	t.Parallel()
+
+	problems := validate(t, `version: 2
+updates:
+  - package-ecosystem: yarn
+    directory: /web
+    schedule:
+      interval: weekly
+  - package-ecosystem: docker
+    directory: /
+    vendor: true
+    versioning-strategy: widen
+    schedule:
+      interval: weekly
+  - package-ecosystem: gitsubmodule
+    directory: /web
+    schedule:
+      interval: weekly
+`)
+	require.Equal(t, []Problem{
+		{Line: 3, Column: 24, Message: `unknown package-ecosystem "yarn", use "npm"`},
+		{Line: 9, Column: 13, Message: `vendor is not supported by package-ecosystem "docker"`},
+		{Line: 10, Column: 26, Message: `versioning-strategy "widen" is not supported by package-ecosystem "docker"`},
+		{Line: 14, Column: 16, Message: `package-ecosystem "gitsubmodule" must use the root directory`},
+	}, problems)
+}
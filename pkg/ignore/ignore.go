@@ -0,0 +1,170 @@
+// Package ignore matches paths against patterns using the gitignore syntax
+// https://git-scm.com/docs/gitignore
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+)
+
+type (
+	// Matcher holds the patterns read from any number of ignore files
+	Matcher struct {
+		patterns []pattern
+	}
+
+	pattern struct {
+		// base is the folder containing the ignore file, relative to the root
+		base string
+		// segments of the pattern split on '/'
+		segments []string
+		negate   bool
+		dirOnly  bool
+		// anchored patterns match from base rather than any folder below it
+		anchored bool
+	}
+)
+
+// New returns an empty Matcher
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Add parses the content of an ignore file found in dir, a slash
+// separated path relative to the root ("" or "." for the root).
+func (m *Matcher) Add(dir string, data []byte) {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if p, ok := parse(dir, scanner.Text()); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+// Match returns true if the slash separated path, relative to the root,
+// is ignored. As with git the last matching pattern wins.
+func (m *Matcher) Match(p string, isDir bool) bool {
+	p = strings.Trim(path.Clean("/"+p), "/")
+
+	ignored := false
+	for _, pat := range m.patterns {
+		if pat.match(p, isDir) {
+			ignored = !pat.negate
+		}
+	}
+	return ignored
+}
+
+// parse converts a line from an ignore file to a pattern
+func parse(base, line string) (pattern, bool) {
+	line = trimTrailingSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	p := pattern{base: base}
+
+	switch {
+	case strings.HasPrefix(line, "!"):
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	// a separator at the start or middle anchors the pattern
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	p.segments = strings.Split(line, "/")
+	return p, true
+}
+
+// trimTrailingSpace removes trailing spaces unless escaped with a backslash
+func trimTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return strings.ReplaceAll(line, `\ `, " ")
+}
+
+func (p pattern) match(target string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	if p.base != "" {
+		if !strings.HasPrefix(target, p.base+"/") {
+			return false
+		}
+		target = strings.TrimPrefix(target, p.base+"/")
+	}
+
+	parts := strings.Split(target, "/")
+
+	if !p.anchored {
+		ok, _ := path.Match(p.segments[0], parts[len(parts)-1])
+		return ok
+	}
+
+	// a trailing '**' matches everything inside but not the folder itself
+	if n := len(p.segments); p.segments[n-1] == "**" && matchSegments(p.segments[:n-1], parts) {
+		return false
+	}
+	return matchSegments(p.segments, parts)
+}
+
+// MatchPath returns true if the slash separated path matches the slash
+// separated pattern. Each segment is matched using path.Match and a
+// '**' segment matches zero or more folders.
+func MatchPath(pattern, p string) bool {
+	return matchSegments(split(pattern), split(p))
+}
+
+// split returns the segments of a slash separated path, none for the root
+func split(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// matchSegments matches a path against the pattern segments where
+// '**' matches zero or more folders
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
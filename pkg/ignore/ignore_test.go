@@ -0,0 +1,78 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Match(t *testing.T) {
+
+	m := New()
+	m.Add("", []byte(`# build output
+/dist
+*.log
+!keep.log
+vendor/
+docs/**/examples
+**/fixtures
+tmp/**
+\#notacomment
+trailing   
+`))
+	m.Add("services/api", []byte(`testdata
+/local
+`))
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "dist", isDir: true, ignored: true},
+		{path: "src/dist", isDir: true, ignored: false},
+		{path: "a/b/debug.log", ignored: true},
+		{path: "a/keep.log", ignored: false},
+		{path: "vendor", isDir: true, ignored: true},
+		{path: "pkg/vendor", isDir: true, ignored: true},
+		{path: "vendor", isDir: false, ignored: false},
+		{path: "docs/examples", isDir: true, ignored: true},
+		{path: "docs/a/b/examples", isDir: true, ignored: true},
+		{path: "x/y/fixtures", isDir: true, ignored: true},
+		{path: "fixtures", isDir: true, ignored: true},
+		{path: "tmp", isDir: true, ignored: false},
+		{path: "tmp/a/go.mod", ignored: true},
+		{path: "#notacomment", ignored: true},
+		{path: "trailing", ignored: true},
+		{path: "services/api/testdata", isDir: true, ignored: true},
+		{path: "services/api/x/testdata", isDir: true, ignored: true},
+		{path: "services/web/testdata", isDir: true, ignored: false},
+		{path: "services/api/local", isDir: true, ignored: true},
+		{path: "services/api/x/local", isDir: true, ignored: false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.ignored, m.Match(tt.path, tt.isDir), tt.path)
+	}
+}
+
+func Test_MatchPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{pattern: "services/*", path: "services/a", match: true},
+		{pattern: "services/*", path: "services/a/b", match: false},
+		{pattern: "services/**", path: "services", match: true},
+		{pattern: "**/web", path: "apps/x/web", match: true},
+		{pattern: "**", path: "", match: true},
+		{pattern: "lib*", path: "src/lib", match: false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.match, MatchPath(tt.pattern, tt.path), "%s %s", tt.pattern, tt.path)
+	}
+}